@@ -2,8 +2,6 @@ package config
 
 import (
 	"os"
-
-	"github.com/pelletier/go-toml"
 )
 
 type App struct {
@@ -23,36 +21,143 @@ type ImagePosition struct {
 type ImageResize struct {
 	Width  string `toml:"width"`
 	Height string `toml:"height"`
+	Filter string `toml:"filter"`
+	Mode   string `toml:"mode"`
+}
+
+type Animation struct {
+	Mode         string  `toml:"mode"`
+	Loops        int     `toml:"loops"`
+	Start        int     `toml:"start"`
+	End          int     `toml:"end"`
+	Speed        float64 `toml:"speed"`
+	DelayPolicy  string  `toml:"delay-policy"`
+	MinDelay     int     `toml:"min-delay"`
+	ExitOnFinish bool    `toml:"exit-on-finish"`
+}
+
+type Cache struct {
+	MemoryBudget     int `toml:"memory-budget"`
+	FrameMemoryLimit int `toml:"frame-memory-limit"`
+}
+
+type Transform struct {
+	Type       string   `toml:"type"`
+	X          int      `toml:"x,omitempty"`
+	Y          int      `toml:"y,omitempty"`
+	Width      int      `toml:"width,omitempty"`
+	Height     int      `toml:"height,omitempty"`
+	Threshold  int      `toml:"threshold,omitempty"`
+	Horizontal bool     `toml:"horizontal,omitempty"`
+	Vertical   bool     `toml:"vertical,omitempty"`
+	Angle      float64  `toml:"angle,omitempty"`
+	Top        int      `toml:"top,omitempty"`
+	Right      int      `toml:"right,omitempty"`
+	Bottom     int      `toml:"bottom,omitempty"`
+	Left       int      `toml:"left,omitempty"`
+	Amount     *float64 `toml:"amount,omitempty"`
+	Color      string   `toml:"color,omitempty"`
+	OffsetX    int      `toml:"offset-x,omitempty"`
+	OffsetY    int      `toml:"offset-y,omitempty"`
+	Blur       float64  `toml:"blur,omitempty"`
+	Radius     float64  `toml:"radius,omitempty"`
+	Tolerance  float64  `toml:"tolerance,omitempty"`
+	Feather    float64  `toml:"feather,omitempty"`
+}
+
+type Overlay struct {
+	Name          string        `toml:"name,omitempty"`
+	ZIndex        int           `toml:"z-index,omitempty"`
+	Locked        bool          `toml:"locked,omitempty"`
+	Image         Image         `toml:"image"`
+	ImagePosition ImagePosition `toml:"image-position,omitempty"`
+	ImageResize   ImageResize   `toml:"image-resize,omitempty"`
+	Animation     Animation     `toml:"animation,omitempty"`
+	Transforms    []Transform   `toml:"transform,omitempty"`
 }
 
 type Config struct {
+	SchemaVersion int           `toml:"schema-version"`
 	App           App           `toml:"app"`
-	Image         Image         `toml:"image"`
-	ImagePosition ImagePosition `toml:"image-position"`
-	ImageResize   ImageResize   `toml:"image-resize"`
+	Image         Image         `toml:"image,omitempty"`
+	ImagePosition ImagePosition `toml:"image-position,omitempty"`
+	ImageResize   ImageResize   `toml:"image-resize,omitempty"`
+	Animation     Animation     `toml:"animation,omitempty"`
+	Cache         Cache         `toml:"cache"`
+	Transforms    []Transform   `toml:"transform,omitempty"`
+	Overlays      []Overlay     `toml:"overlay,omitempty"`
 }
 
-func Load(configPath string) (*Config, error) {
-	var config Config
+func (c *Config) AllOverlays() []Overlay {
+	var overlays []Overlay
+
+	if c.Image.Source != "" {
+		overlays = append(overlays, c.primaryOverlay())
+	}
+
+	return append(overlays, c.Overlays...)
+}
 
+func (c *Config) primaryOverlay() Overlay {
+	return Overlay{
+		Image:         c.Image,
+		ImagePosition: c.ImagePosition,
+		ImageResize:   c.ImageResize,
+		Animation:     c.Animation,
+		Transforms:    c.Transforms,
+	}
+}
+
+func (c *Config) SetOverlayPosition(index, x, y int) {
+	if c.Image.Source != "" {
+		if index == 0 {
+			c.ImagePosition = ImagePosition{X: x, Y: y}
+			return
+		}
+		index--
+	}
+
+	if index >= 0 && index < len(c.Overlays) {
+		c.Overlays[index].ImagePosition = ImagePosition{X: x, Y: y}
+	}
+}
+
+func Load(configPath string) (*Config, error) {
 	data, err := os.ReadFile(configPath)
 	if err != nil {
 		return nil, err
 	}
 
-	if err := toml.Unmarshal(data, &config); err != nil {
+	config, applied, err := validate(configPath, data)
+	if err != nil {
 		return nil, err
 	}
 
-	return &config, nil
+	if len(applied) > 0 {
+		if _, err := upgradeFile(configPath, data, config, applied); err != nil {
+			return nil, err
+		}
+	}
+	return config, nil
 }
 
-func Save(configPath string, config *Config) error {
-	data, err := toml.Marshal(config)
-
+func Read(configPath string) (*Config, error) {
+	data, err := os.ReadFile(configPath)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	return os.WriteFile(configPath, data, 0644)
+	config, _, err := validate(configPath, data)
+	return config, err
+}
+
+func (c *Config) Clone() *Config {
+	clone := *c
+	clone.Transforms = append([]Transform(nil), c.Transforms...)
+	clone.Overlays = nil
+	for _, overlay := range c.Overlays {
+		overlay.Transforms = append([]Transform(nil), overlay.Transforms...)
+		clone.Overlays = append(clone.Overlays, overlay)
+	}
+	return &clone
 }
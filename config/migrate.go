@@ -0,0 +1,427 @@
+package config
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pelletier/go-toml"
+)
+
+const CurrentSchema = 3
+
+type migration struct {
+	from        int
+	description string
+	apply       func(tree *toml.Tree) error
+}
+
+var migrations = []migration{
+	{
+		from:        1,
+		description: "move a top-level image path into [image] source",
+		apply:       migrateImagePath,
+	},
+	{
+		from:        2,
+		description: "move the single image settings into an [[overlay]] table",
+		apply:       migrateSingleOverlay,
+	},
+}
+
+var overlayKeys = []string{"image", "image-position", "image-resize", "animation", "transform"}
+
+func schemaVersion(tree *toml.Tree) (int, error) {
+	if !tree.Has("schema-version") {
+		return 1, nil
+	}
+
+	version, ok := tree.Get("schema-version").(int64)
+	if !ok || version < 1 {
+		return 0, fmt.Errorf("schema-version must be a positive integer")
+	}
+	if version > CurrentSchema {
+		return 0, fmt.Errorf("schema-version %d is newer than this visualio supports (%d), please update visualio", version, CurrentSchema)
+	}
+	return int(version), nil
+}
+
+func migrate(tree *toml.Tree) ([]migration, error) {
+	version, err := schemaVersion(tree)
+	if err != nil {
+		return nil, err
+	}
+
+	var applied []migration
+	for version < CurrentSchema {
+		step, ok := findMigration(version)
+		if !ok {
+			return nil, fmt.Errorf("no migration from schema %d", version)
+		}
+		if err := step.apply(tree); err != nil {
+			return nil, fmt.Errorf("migrating schema %d to %d: %w", version, version+1, err)
+		}
+		applied = append(applied, step)
+		version++
+	}
+
+	if len(applied) > 0 {
+		tree.Set("schema-version", int64(CurrentSchema))
+	}
+	return applied, nil
+}
+
+func findMigration(from int) (migration, bool) {
+	for _, m := range migrations {
+		if m.from == from {
+			return m, true
+		}
+	}
+	return migration{}, false
+}
+
+func migrateImagePath(tree *toml.Tree) error {
+	source, ok := tree.Get("image").(string)
+	if !ok {
+		return nil
+	}
+
+	position := tree.GetPosition("image")
+	if err := tree.Delete("image"); err != nil {
+		return err
+	}
+	tree.SetPath([]string{"image", "source"}, source)
+	tree.SetPositionPath([]string{"image"}, position)
+	tree.SetPositionPath([]string{"image", "source"}, position)
+	return nil
+}
+
+func hasImageSource(tree *toml.Tree) bool {
+	switch image := tree.Get("image").(type) {
+	case string:
+		return image != ""
+	case *toml.Tree:
+		source, _ := image.Get("source").(string)
+		return source != ""
+	}
+	return false
+}
+
+func migrateSingleOverlay(tree *toml.Tree) error {
+	if !hasImageSource(tree) {
+		return nil
+	}
+
+	overlay, err := toml.TreeFromMap(map[string]any{})
+	if err != nil {
+		return err
+	}
+
+	for _, key := range overlayKeys {
+		if !tree.Has(key) {
+			continue
+		}
+		overlay.Set(key, tree.Get(key))
+		if err := tree.Delete(key); err != nil {
+			return err
+		}
+	}
+
+	overlays := []*toml.Tree{overlay}
+	if tree.Has("overlay") {
+		existing, ok := tree.Get("overlay").([]*toml.Tree)
+		if !ok {
+			return fmt.Errorf("overlay must be an array of tables")
+		}
+		overlays = append(overlays, existing...)
+	}
+	tree.Set("overlay", overlays)
+	return nil
+}
+
+func Migrate(configPath string) (backup string, steps []string, err error) {
+	data, err := os.ReadFile(configPath)
+	if err != nil {
+		return "", nil, err
+	}
+
+	config, applied, err := validate(configPath, data)
+	if err != nil || len(applied) == 0 {
+		return "", nil, err
+	}
+
+	backup, err = upgradeFile(configPath, data, config, applied)
+	if err != nil {
+		return "", nil, err
+	}
+
+	for _, step := range applied {
+		steps = append(steps, fmt.Sprintf("schema %d -> %d: %s", step.from, step.from+1, step.description))
+	}
+	return backup, steps, nil
+}
+
+func upgradeFile(configPath string, original []byte, config *Config, applied []migration) (string, error) {
+	backup := fmt.Sprintf("%s.v%d-%s.bak", configPath, applied[0].from, time.Now().Format("20060102-150405"))
+	if err := os.WriteFile(backup, original, 0644); err != nil {
+		return "", fmt.Errorf("backing up config before migration: %w", err)
+	}
+
+	data, err := upgradeDocument(original, config)
+	if err != nil {
+		return "", err
+	}
+
+	if err := writeFileAtomic(configPath, data); err != nil {
+		return "", err
+	}
+	return backup, nil
+}
+
+func upgradeDocument(data []byte, config *Config) ([]byte, error) {
+	if rewritten, err := rewriteDocument(data); err == nil && sameConfig(rewritten, config) {
+		return rewritten, nil
+	}
+
+	tree, err := toml.LoadBytes(data)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := migrate(tree); err != nil {
+		return nil, err
+	}
+	return tree.Marshal()
+}
+
+func sameConfig(data []byte, config *Config) bool {
+	tree, err := toml.LoadBytes(data)
+	if err != nil {
+		return false
+	}
+	if version, err := schemaVersion(tree); err != nil || version != CurrentSchema {
+		return false
+	}
+
+	var result Config
+	if err := tree.Unmarshal(&result); err != nil {
+		return false
+	}
+	return reflect.DeepEqual(&result, config)
+}
+
+type documentBlock struct {
+	key    string
+	header bool
+	lines  []string
+}
+
+func rewriteDocument(data []byte) ([]byte, error) {
+	tree, err := toml.LoadBytes(data)
+	if err != nil {
+		return nil, err
+	}
+
+	newline := "\n"
+	if bytes.Contains(data, []byte("\r\n")) {
+		newline = "\r\n"
+	}
+	text := strings.TrimSuffix(string(data), newline)
+
+	move := hasImageSource(tree)
+	_, path := tree.Get("image").(string)
+	schema := false
+	at := -1
+
+	blocks := splitDocument(strings.Split(text, newline))
+	indent := keyIndent(blocks)
+
+	var out, keys, image, tables []string
+	for _, block := range blocks {
+		line := firstLine(block.lines)
+
+		switch {
+		case !block.header && block.key == "schema-version":
+			replaced, err := replaceValue(block.lines[line], block.key, strconv.Itoa(CurrentSchema))
+			if err != nil {
+				return nil, err
+			}
+			block.lines[line] = replaced
+			schema = true
+		case move && slices.Contains(overlayKeys, block.key):
+			switch {
+			case block.header:
+				if at < 0 {
+					at = len(out)
+				}
+				block.lines[line] = nestHeader(block.lines[line], "overlay")
+				tables = append(tables, block.lines...)
+			case block.key == "image" && path:
+				_, value, _ := strings.Cut(block.lines[line], "=")
+				image = append(image, block.lines[:line]...)
+				image = append(image, "[overlay.image]", indent+"source = "+strings.TrimSpace(value))
+				image = append(image, block.lines[line+1:]...)
+			default:
+				keys = append(keys, block.lines...)
+			}
+			continue
+		case block.header && block.key == "overlay":
+			if at < 0 {
+				at = len(out)
+			}
+		}
+		out = append(out, block.lines...)
+	}
+
+	if move {
+		overlay := append([]string{"[[overlay]]"}, keys...)
+		overlay = append(overlay, image...)
+		overlay = append(overlay, tables...)
+
+		if at < 0 {
+			at = len(out)
+		} else if strings.TrimSpace(overlay[len(overlay)-1]) != "" {
+			overlay = append(overlay, "")
+		}
+		if at > 0 && strings.TrimSpace(out[at-1]) != "" {
+			overlay = append([]string{""}, overlay...)
+		}
+		out = slices.Insert(out, at, overlay...)
+	}
+
+	if !schema {
+		header := []string{"schema-version = " + strconv.Itoa(CurrentSchema)}
+		if len(out) > 0 && strings.TrimSpace(out[0]) != "" {
+			header = append(header, "")
+		}
+		out = slices.Insert(out, 0, header...)
+	}
+
+	for len(out) > 0 && strings.TrimSpace(out[len(out)-1]) == "" {
+		out = out[:len(out)-1]
+	}
+
+	return []byte(strings.Join(out, newline) + newline), nil
+}
+
+func splitDocument(lines []string) []documentBlock {
+	var blocks []documentBlock
+	var current documentBlock
+	var comments []string
+	var state scanState
+
+	for _, line := range lines {
+		trimmed := strings.TrimSpace(line)
+		clean := state.clean()
+
+		if clean && strings.HasPrefix(trimmed, "#") {
+			comments = append(comments, line)
+			continue
+		}
+
+		header := clean && strings.HasPrefix(trimmed, "[")
+		if clean && trimmed != "" && (header || !current.header) {
+			blocks = append(blocks, current)
+			current = documentBlock{key: rootKey(trimmed), header: header}
+		}
+
+		current.lines = append(current.lines, comments...)
+		current.lines = append(current.lines, line)
+		comments = nil
+
+		if !header {
+			state.scan(line)
+		}
+	}
+
+	current.lines = append(current.lines, comments...)
+	return append(blocks, current)
+}
+
+func keyIndent(blocks []documentBlock) string {
+	for _, block := range blocks {
+		if !block.header {
+			continue
+		}
+		for _, line := range block.lines[firstLine(block.lines)+1:] {
+			if trimmed := strings.TrimSpace(line); trimmed != "" && !strings.HasPrefix(trimmed, "#") && !strings.HasPrefix(trimmed, "[") {
+				return line[:len(line)-len(strings.TrimLeft(line, " \t"))]
+			}
+		}
+	}
+	return "  "
+}
+
+func firstLine(lines []string) int {
+	for i, line := range lines {
+		if trimmed := strings.TrimSpace(line); trimmed != "" && !strings.HasPrefix(trimmed, "#") {
+			return i
+		}
+	}
+	return 0
+}
+
+func rootKey(line string) string {
+	line = strings.TrimLeft(line, "[ \t")
+	if end := strings.IndexAny(line, ".=]"); end >= 0 {
+		line = line[:end]
+	}
+	return strings.Trim(strings.TrimSpace(line), `"'`)
+}
+
+func nestHeader(line, parent string) string {
+	start := strings.IndexByte(line, '[')
+	open := start + 1
+	if strings.HasPrefix(line[start:], "[[") {
+		open++
+	}
+	return line[:open] + parent + "." + strings.TrimLeft(line[open:], " \t")
+}
+
+type scanState struct {
+	quote string
+	depth int
+}
+
+func (s *scanState) clean() bool {
+	return s.quote == "" && s.depth == 0
+}
+
+func (s *scanState) scan(line string) {
+	for i := 0; i < len(line); i++ {
+		if s.quote != "" {
+			switch {
+			case strings.HasPrefix(line[i:], s.quote):
+				i += len(s.quote) - 1
+				s.quote = ""
+			case line[i] == '\\' && s.quote == `"""`:
+				i++
+			}
+			continue
+		}
+
+		switch c := line[i]; c {
+		case '#':
+			return
+		case '[', '{':
+			s.depth++
+		case ']', '}':
+			s.depth--
+		case '"', '\'':
+			if delimiter := strings.Repeat(string(c), 3); strings.HasPrefix(line[i:], delimiter) {
+				s.quote = delimiter
+				i += 2
+				continue
+			}
+			for i++; i < len(line) && line[i] != c; i++ {
+				if c == '"' && line[i] == '\\' {
+					i++
+				}
+			}
+		}
+	}
+}
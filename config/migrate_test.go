@@ -0,0 +1,195 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestUpgradeDocumentKeepsText(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{
+			name: "v1 image path",
+			input: `# visualio settings
+
+# the mascot
+image = "mascot.png" # pinned
+
+[app]
+  update-check = false   # no nagging
+
+# where it sits
+[image-position]
+  x = 4
+  y = 5
+
+[[transform]]
+  type = "outline"
+  width = 2
+
+[cache]
+  memory-budget = 1000
+`,
+			want: `schema-version = 3
+
+# visualio settings
+
+[app]
+  update-check = false   # no nagging
+
+[[overlay]]
+# the mascot
+[overlay.image]
+  source = "mascot.png" # pinned
+
+# where it sits
+[overlay.image-position]
+  x = 4
+  y = 5
+
+[[overlay.transform]]
+  type = "outline"
+  width = 2
+
+[cache]
+  memory-budget = 1000
+`,
+		},
+		{
+			name: "v2 image table before existing overlays",
+			input: `schema-version = 2  # keep
+image-position = { x = 1, y = 2 }
+
+[image]
+    source = "mascot.png"
+
+[[overlay]]
+    name = "second"
+    [overlay.image]
+        source = "second.png"
+
+[animation]
+    speed = 2.0
+`,
+			want: `schema-version = 3  # keep
+
+[[overlay]]
+image-position = { x = 1, y = 2 }
+
+[overlay.image]
+    source = "mascot.png"
+
+[overlay.animation]
+    speed = 2.0
+
+[[overlay]]
+    name = "second"
+    [overlay.image]
+        source = "second.png"
+`,
+		},
+		{
+			name:  "no single image only bumps the version",
+			input: "schema-version = 2\r\n\r\n[image-resize]\r\n  width = \"50%\"\r\n\r\n[[overlay]]\r\n  [overlay.image]\r\n    source = \"a.png\"\r\n",
+			want:  "schema-version = 3\r\n\r\n[image-resize]\r\n  width = \"50%\"\r\n\r\n[[overlay]]\r\n  [overlay.image]\r\n    source = \"a.png\"\r\n",
+		},
+		{
+			name:  "leading blank line is not doubled",
+			input: "\n[image]\n  source = \"a.png\"\n",
+			want:  "schema-version = 3\n\n[[overlay]]\n[overlay.image]\n  source = \"a.png\"\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			for _, image := range []string{"mascot.png", "second.png", "a.png"} {
+				writeTestImage(t, filepath.Join(dir, image))
+			}
+			t.Chdir(dir)
+
+			config, applied, err := validate("config.toml", []byte(tt.input))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(applied) == 0 {
+				t.Fatal("no migrations applied")
+			}
+
+			got, err := upgradeDocument([]byte(tt.input), config)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(got) != tt.want {
+				t.Fatalf("upgraded document:\n%s\nwant:\n%s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestUpgradeDocumentFallbackSkipsDefaults(t *testing.T) {
+	input := []byte("image = \"mascot.png\"\n[cache]\n  memory-budget = 1000\n")
+
+	got, err := upgradeDocument(input, &Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, key := range []string{"filter", "mode", "frame-memory-limit", "update-check"} {
+		if strings.Contains(string(got), key) {
+			t.Fatalf("fallback wrote default %s:\n%s", key, got)
+		}
+	}
+	if !strings.Contains(string(got), "memory-budget = 1000") || !strings.Contains(string(got), "[[overlay]]") {
+		t.Fatalf("fallback lost settings:\n%s", got)
+	}
+}
+
+func TestValidateLegacyKeys(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  []string
+	}{
+		{
+			name:  "v1",
+			input: "image = \"missing.png\"\n[image-resize]\n  mode = \"zoom\"\n",
+			want:  []string{"1:1: image: ", "3:3: image-resize.mode: "},
+		},
+		{
+			name:  "v2",
+			input: "schema-version = 2\n[image]\n  source = \"missing.png\"\n[[overlay]]\n  [overlay.image]\n    source = \"other.png\"\n",
+			want:  []string{"3:3: image.source: ", "6:5: overlay[1].image.source: "},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Chdir(t.TempDir())
+
+			_, _, err := validate("config.toml", []byte(tt.input))
+			problems := err.(*ValidationError).Problems
+			if len(problems) != len(tt.want) {
+				t.Fatalf("problems = %v, want %d", problems, len(tt.want))
+			}
+			for i, problem := range problems {
+				if !strings.HasPrefix(problem.String(), tt.want[i]) {
+					t.Errorf("problem %d = %q, want prefix %q", i, problem, tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+func writeTestImage(t *testing.T, path string) {
+	t.Helper()
+
+	png := []byte("\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR\x00\x00\x00\x01\x00\x00\x00\x01\x08\x06\x00\x00\x00\x1f\x15\xc4\x89\x00\x00\x00\rIDATx\x9cc\xf8\x0f\x00\x00\x01\x01\x00\x05\x18\xd8N\x00\x00\x00\x00IEND\xaeB`\x82")
+	if err := os.WriteFile(path, png, 0644); err != nil {
+		t.Fatal(err)
+	}
+}
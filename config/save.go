@@ -0,0 +1,438 @@
+package config
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/pelletier/go-toml"
+)
+
+var errStructural = errors.New("config layout changed")
+
+type keyPath []any
+
+func (p keyPath) String() string {
+	var b strings.Builder
+	for _, part := range p {
+		switch part := part.(type) {
+		case string:
+			if b.Len() > 0 {
+				b.WriteByte('.')
+			}
+			b.WriteString(part)
+		case int:
+			fmt.Fprintf(&b, "[%d]", part+1)
+		}
+	}
+	return b.String()
+}
+
+type edit struct {
+	path  keyPath
+	value reflect.Value
+}
+
+func encode(data []byte, previous, next *Config) ([]byte, error) {
+	if len(data) == 0 {
+		return toml.Marshal(next)
+	}
+
+	patched, err := patchDocument(data, previous, next)
+	if err != nil {
+		return nil, fmt.Errorf("config file left unchanged, cannot update it in place: %w", err)
+	}
+	return patched, nil
+}
+
+func writeFileAtomic(path string, data []byte) error {
+	mode := os.FileMode(0644)
+	if info, err := os.Stat(path); err == nil {
+		mode = info.Mode().Perm()
+	}
+
+	temp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(temp.Name())
+
+	if _, err := temp.Write(data); err != nil {
+		temp.Close()
+		return err
+	}
+	if err := temp.Sync(); err != nil {
+		temp.Close()
+		return err
+	}
+	if err := temp.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(temp.Name(), mode); err != nil {
+		return err
+	}
+
+	return os.Rename(temp.Name(), path)
+}
+
+func diffConfig(previous, next *Config) ([]edit, error) {
+	var edits []edit
+	err := diffValue(nil, reflect.ValueOf(previous).Elem(), reflect.ValueOf(next).Elem(), &edits)
+	return edits, err
+}
+
+func diffValue(path keyPath, previous, next reflect.Value, edits *[]edit) error {
+	switch next.Kind() {
+	case reflect.Struct:
+		for i := range next.NumField() {
+			name, _, _ := strings.Cut(next.Type().Field(i).Tag.Get("toml"), ",")
+			if name == "" || name == "-" {
+				continue
+			}
+			if err := diffValue(append(path[:len(path):len(path)], name), previous.Field(i), next.Field(i), edits); err != nil {
+				return err
+			}
+		}
+		return nil
+	case reflect.Slice:
+		if previous.Len() != next.Len() {
+			return fmt.Errorf("%w: %s", errStructural, path)
+		}
+		for i := range next.Len() {
+			if err := diffValue(append(path[:len(path):len(path)], i), previous.Index(i), next.Index(i), edits); err != nil {
+				return err
+			}
+		}
+		return nil
+	case reflect.Pointer:
+		if previous.IsNil() && next.IsNil() {
+			return nil
+		}
+		if next.IsNil() {
+			return fmt.Errorf("%w: %s", errStructural, path)
+		}
+		if !previous.IsNil() && previous.Elem().Interface() == next.Elem().Interface() {
+			return nil
+		}
+		*edits = append(*edits, edit{path: path, value: next.Elem()})
+		return nil
+	}
+
+	if previous.Interface() != next.Interface() {
+		*edits = append(*edits, edit{path: path, value: next})
+	}
+	return nil
+}
+
+func patchDocument(data []byte, previous, next *Config) ([]byte, error) {
+	edits, err := diffConfig(previous, next)
+	if err != nil {
+		return nil, err
+	}
+	if len(edits) == 0 {
+		return data, nil
+	}
+
+	tree, err := toml.LoadBytes(data)
+	if err != nil {
+		return nil, err
+	}
+
+	var original Config
+	if err := tree.Unmarshal(&original); err != nil {
+		return nil, err
+	}
+
+	newline := "\n"
+	if bytes.Contains(data, []byte("\r\n")) {
+		newline = "\r\n"
+	}
+	lines := strings.Split(string(data), newline)
+
+	var inserts []*lineInsert
+	created := make(map[string]*lineInsert)
+
+	for _, e := range edits {
+		location, err := resolveKey(tree, e.path)
+		if err != nil {
+			return nil, err
+		}
+
+		value, err := formatValue(e.value)
+		if err != nil {
+			return nil, err
+		}
+
+		if location.table == nil {
+			insert, ok := created[location.header]
+			if !ok {
+				insert, err = newTable(tree, location, lines)
+				if err != nil {
+					return nil, err
+				}
+				created[location.header] = insert
+				inserts = append(inserts, insert)
+			}
+			insert.text = append(insert.text, insert.indent+location.key+" = "+value)
+			continue
+		}
+
+		if location.table.Has(location.key) {
+			position := location.table.GetPosition(location.key)
+			line, err := replaceValue(lines[position.Line-1], location.key, value)
+			if err != nil {
+				return nil, err
+			}
+			lines[position.Line-1] = line
+			continue
+		}
+
+		after, indent, err := tableEnd(location.table, lines)
+		if err != nil {
+			return nil, err
+		}
+		inserts = append(inserts, &lineInsert{after: after, indent: indent, text: []string{indent + location.key + " = " + value}})
+	}
+
+	sort.SliceStable(inserts, func(i, j int) bool {
+		return inserts[i].after < inserts[j].after
+	})
+	for i := len(inserts) - 1; i >= 0; i-- {
+		insert := inserts[i]
+		lines = append(lines[:insert.after], append(insert.text, lines[insert.after:]...)...)
+	}
+
+	patched := []byte(strings.Join(lines, newline))
+	if err := verifyPatch(patched, &original, next, edits); err != nil {
+		return nil, err
+	}
+	return patched, nil
+}
+
+type lineInsert struct {
+	after  int
+	indent string
+	text   []string
+}
+
+type keyLocation struct {
+	table  *toml.Tree
+	parent *toml.Tree
+	header string
+	key    string
+}
+
+func resolveKey(tree *toml.Tree, path keyPath) (keyLocation, error) {
+	location := keyLocation{table: tree}
+	var header []string
+
+	for i := 0; i < len(path)-1; i++ {
+		key, ok := path[i].(string)
+		if !ok || location.table == nil {
+			return keyLocation{}, fmt.Errorf("%w: %s", errStructural, path)
+		}
+		header = append(header, key)
+
+		switch value := location.table.Get(key).(type) {
+		case *toml.Tree:
+			location.table = value
+		case []*toml.Tree:
+			index, ok := path[i+1].(int)
+			if !ok || index >= len(value) {
+				return keyLocation{}, fmt.Errorf("%w: %s", errStructural, path)
+			}
+			location.table = value[index]
+			i++
+		case nil:
+			location.parent, location.table = location.table, nil
+		default:
+			return keyLocation{}, fmt.Errorf("%w: %s", errStructural, path)
+		}
+	}
+
+	key, ok := path[len(path)-1].(string)
+	if !ok {
+		return keyLocation{}, fmt.Errorf("%w: %s", errStructural, path)
+	}
+
+	location.header = strings.Join(header, ".")
+	location.key = key
+	return location, nil
+}
+
+func newTable(tree *toml.Tree, location keyLocation, lines []string) (*lineInsert, error) {
+	if location.parent == tree {
+		after := len(lines)
+		if after > 0 && lines[after-1] == "" {
+			after--
+		}
+		return &lineInsert{
+			after:  after,
+			indent: "  ",
+			text:   []string{"", "[" + location.header + "]"},
+		}, nil
+	}
+
+	after, indent, err := tableEnd(location.parent, lines)
+	if err != nil {
+		return nil, err
+	}
+	return &lineInsert{
+		after:  after,
+		indent: indent + "  ",
+		text:   []string{indent + "[" + location.header + "]"},
+	}, nil
+}
+
+func tableEnd(table *toml.Tree, lines []string) (int, string, error) {
+	last := table.Position().Line
+	if last <= 0 || last > len(lines) {
+		return 0, "", fmt.Errorf("%w: table has no header", errStructural)
+	}
+
+	header := lines[last-1]
+	indent := header[:len(header)-len(strings.TrimLeft(header, " \t"))] + "  "
+
+	for _, key := range table.Keys() {
+		switch table.Get(key).(type) {
+		case *toml.Tree, []*toml.Tree:
+			continue
+		}
+		position := table.GetPosition(key)
+		if position.Line >= last {
+			last = position.Line
+			indent = lines[position.Line-1][:position.Col-1]
+		}
+	}
+
+	return last, indent, nil
+}
+
+func replaceValue(line, key, value string) (string, error) {
+	trimmed := strings.TrimLeft(line, " \t")
+	if !strings.HasPrefix(trimmed, key) {
+		return "", fmt.Errorf("%w: key %s is not on its own line", errStructural, key)
+	}
+
+	start := len(line) - len(trimmed) + len(key)
+	rest := strings.TrimLeft(line[start:], " \t")
+	if !strings.HasPrefix(rest, "=") {
+		return "", fmt.Errorf("%w: key %s has no value", errStructural, key)
+	}
+
+	valueStart := len(line) - len(strings.TrimLeft(rest[1:], " \t"))
+	end, err := valueEnd(line[valueStart:])
+	if err != nil {
+		return "", err
+	}
+
+	return line[:valueStart] + value + line[valueStart+end:], nil
+}
+
+func valueEnd(text string) (int, error) {
+	switch {
+	case strings.HasPrefix(text, `"""`), strings.HasPrefix(text, `'''`), strings.HasPrefix(text, "["), strings.HasPrefix(text, "{"):
+		return 0, fmt.Errorf("%w: value is not a single-line scalar", errStructural)
+	case strings.HasPrefix(text, `"`):
+		for i := 1; i < len(text); i++ {
+			switch text[i] {
+			case '\\':
+				i++
+			case '"':
+				return i + 1, nil
+			}
+		}
+		return 0, fmt.Errorf("%w: unterminated string", errStructural)
+	case strings.HasPrefix(text, "'"):
+		if end := strings.IndexByte(text[1:], '\''); end >= 0 {
+			return end + 2, nil
+		}
+		return 0, fmt.Errorf("%w: unterminated string", errStructural)
+	}
+
+	if end := strings.IndexAny(text, " \t#"); end >= 0 {
+		return end, nil
+	}
+	return len(text), nil
+}
+
+func formatValue(value reflect.Value) (string, error) {
+	switch value.Kind() {
+	case reflect.String:
+		return quoteString(value.String()), nil
+	case reflect.Int:
+		return strconv.FormatInt(value.Int(), 10), nil
+	case reflect.Float64:
+		text := strconv.FormatFloat(value.Float(), 'f', -1, 64)
+		if !strings.ContainsAny(text, ".eE") {
+			text += ".0"
+		}
+		return text, nil
+	case reflect.Bool:
+		return strconv.FormatBool(value.Bool()), nil
+	}
+	return "", fmt.Errorf("%w: cannot write %s values", errStructural, value.Kind())
+}
+
+func quoteString(s string) string {
+	var b strings.Builder
+	b.WriteByte('"')
+	for _, r := range s {
+		switch {
+		case r == '"' || r == '\\':
+			b.WriteByte('\\')
+			b.WriteRune(r)
+		case r == '\n':
+			b.WriteString(`\n`)
+		case r == '\t':
+			b.WriteString(`\t`)
+		case r == '\r':
+			b.WriteString(`\r`)
+		case r < 0x20 || r == 0x7f:
+			fmt.Fprintf(&b, `\u%04X`, r)
+		default:
+			b.WriteRune(r)
+		}
+	}
+	b.WriteByte('"')
+	return b.String()
+}
+
+func verifyPatch(patched []byte, original, next *Config, edits []edit) error {
+	var result Config
+	if err := toml.Unmarshal(patched, &result); err != nil {
+		return err
+	}
+
+	edited := make(map[string]bool, len(edits))
+	for _, e := range edits {
+		edited[e.path.String()] = true
+	}
+
+	changed, err := diffConfig(original, &result)
+	if err != nil {
+		return err
+	}
+	for _, e := range changed {
+		if !edited[e.path.String()] {
+			return fmt.Errorf("patch changed %s unexpectedly", e.path)
+		}
+	}
+
+	remaining, err := diffConfig(&result, next)
+	if err != nil {
+		return err
+	}
+	for _, e := range remaining {
+		if edited[e.path.String()] {
+			return fmt.Errorf("patch did not apply %s", e.path)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,272 @@
+package config
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pelletier/go-toml"
+)
+
+const twoOverlays = `schema-version = 3
+
+# the app
+[app]
+  version = "v1"
+
+[[overlay]]
+  name = "a"   # first
+  [overlay.image]
+    source = "a.png"
+  [overlay.image-position]
+    y = 2
+    x = 1 # left edge
+
+[[overlay]]
+  name = "b"
+  [overlay.image]
+    source = "b.png"
+`
+
+func parseConfig(t *testing.T, data string) *Config {
+	t.Helper()
+
+	var config Config
+	if err := toml.Unmarshal([]byte(data), &config); err != nil {
+		t.Fatal(err)
+	}
+	return &config
+}
+
+func TestPatchDocument(t *testing.T) {
+	tests := []struct {
+		name   string
+		input  string
+		change func(*Config)
+		want   string
+	}{
+		{
+			name:   "one key keeps comments and order",
+			input:  twoOverlays,
+			change: func(c *Config) { c.Overlays[0].ImagePosition.Y = 20 },
+			want:   strings.Replace(twoOverlays, "y = 2\n", "y = 20\n", 1),
+		},
+		{
+			name:   "trailing comment kept",
+			input:  twoOverlays,
+			change: func(c *Config) { c.Overlays[0].ImagePosition.X = 300 },
+			want:   strings.Replace(twoOverlays, "x = 1 # left edge", "x = 300 # left edge", 1),
+		},
+		{
+			name:   "string value with comment",
+			input:  twoOverlays,
+			change: func(c *Config) { c.Overlays[0].Name = `say "hi"` },
+			want:   strings.Replace(twoOverlays, `name = "a"   # first`, `name = "say \"hi\""   # first`, 1),
+		},
+		{
+			name:   "sub-table goes into its own overlay",
+			input:  twoOverlays,
+			change: func(c *Config) { c.Overlays[1].ImagePosition = ImagePosition{X: 5, Y: 6} },
+			want: strings.Replace(twoOverlays, `  name = "b"
+`, `  name = "b"
+  [overlay.image-position]
+    x = 5
+    y = 6
+`, 1),
+		},
+		{
+			name:   "missing key appended to its table",
+			input:  twoOverlays,
+			change: func(c *Config) { c.App.UpdateCheck = true },
+			want:   strings.Replace(twoOverlays, "  version = \"v1\"\n", "  version = \"v1\"\n  update-check = true\n", 1),
+		},
+		{
+			name:   "missing top-level table appended",
+			input:  twoOverlays,
+			change: func(c *Config) { c.Cache.MemoryBudget = 1024 },
+			want:   twoOverlays + "\n[cache]\n  memory-budget = 1024\n",
+		},
+		{
+			name:   "crlf",
+			input:  strings.ReplaceAll(twoOverlays, "\n", "\r\n"),
+			change: func(c *Config) { c.Overlays[1].ImagePosition = ImagePosition{X: 5} },
+			want: strings.ReplaceAll(strings.Replace(twoOverlays, `  name = "b"
+`, `  name = "b"
+  [overlay.image-position]
+    x = 5
+`, 1), "\n", "\r\n"),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			previous := parseConfig(t, tt.input)
+			next := previous.Clone()
+			tt.change(next)
+
+			got, err := patchDocument([]byte(tt.input), previous, next)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(got) != tt.want {
+				t.Fatalf("patched:\n%s\nwant:\n%s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEncodeKeepsFileOnStructuralChange(t *testing.T) {
+	input := strings.Replace(twoOverlays, `name = "a"   # first`, "name = \"\"\"\na\"\"\"", 1)
+
+	tests := map[string]func(*Config){
+		"multi-line value": func(c *Config) { c.Overlays[0].Name = "c" },
+		"overlay removed":  func(c *Config) { c.Overlays = c.Overlays[:1] },
+	}
+
+	for name, change := range tests {
+		t.Run(name, func(t *testing.T) {
+			previous := parseConfig(t, input)
+			next := previous.Clone()
+			change(next)
+
+			got, err := encode([]byte(input), previous, next)
+			if err == nil {
+				t.Fatalf("encode rewrote the file:\n%s", got)
+			}
+			if name == "overlay removed" && !errors.Is(err, errStructural) {
+				t.Fatalf("err = %v, want a structural error", err)
+			}
+		})
+	}
+}
+
+func TestReplaceValue(t *testing.T) {
+	tests := []struct {
+		line, key, value string
+		want             string
+		err              bool
+	}{
+		{line: "x = 1", key: "x", value: "2", want: "x = 2"},
+		{line: "    x=1   # note", key: "x", value: "20", want: "    x=20   # note"},
+		{line: `source = "a \" # b" # c`, key: "source", value: `"d"`, want: `source = "d" # c`},
+		{line: `source = 'C:\a' # c`, key: "source", value: `"d"`, want: `source = "d" # c`},
+		{line: "speed = 1.5#fast", key: "speed", value: "2.0", want: "speed = 2.0#fast"},
+		{line: "x = [1, 2]", key: "x", value: "3", err: true},
+		{line: `x = """a`, key: "x", value: "3", err: true},
+		{line: `x = "open`, key: "x", value: "3", err: true},
+		{line: "a = 1, x = 2", key: "x", value: "3", err: true},
+		{line: "x", key: "x", value: "3", err: true},
+	}
+
+	for _, tt := range tests {
+		got, err := replaceValue(tt.line, tt.key, tt.value)
+		if tt.err {
+			if !errors.Is(err, errStructural) {
+				t.Errorf("replaceValue(%q) = %q, %v, want a structural error", tt.line, got, err)
+			}
+			continue
+		}
+		if err != nil || got != tt.want {
+			t.Errorf("replaceValue(%q) = %q, %v, want %q", tt.line, got, err, tt.want)
+		}
+	}
+}
+
+func TestTableEnd(t *testing.T) {
+	tree, err := toml.Load(twoOverlays)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(twoOverlays, "\n")
+
+	overlays := tree.Get("overlay").([]*toml.Tree)
+	tests := []struct {
+		name   string
+		table  *toml.Tree
+		after  int
+		indent string
+	}{
+		{name: "app", table: tree.Get("app").(*toml.Tree), after: 5, indent: "  "},
+		{name: "overlay with sub-tables", table: overlays[0], after: 8, indent: "  "},
+		{name: "nested table", table: overlays[0].Get("image-position").(*toml.Tree), after: 13, indent: "    "},
+	}
+
+	for _, tt := range tests {
+		after, indent, err := tableEnd(tt.table, lines)
+		if err != nil || after != tt.after || indent != tt.indent {
+			t.Errorf("%s: tableEnd = %d, %q, %v, want %d, %q", tt.name, after, indent, err, tt.after, tt.indent)
+		}
+	}
+}
+
+func TestWriteFileAtomicKeepsMode(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("file modes are not preserved on windows")
+	}
+
+	dir := t.TempDir()
+	path := filepath.Join(dir, "config.toml")
+	if err := os.WriteFile(path, []byte("old"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := writeFileAtomic(path, []byte("new")); err != nil {
+		t.Fatal(err)
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Mode().Perm() != 0600 {
+		t.Fatalf("mode = %v, want 0600", info.Mode().Perm())
+	}
+	if data, _ := os.ReadFile(path); string(data) != "new" {
+		t.Fatalf("content = %q", data)
+	}
+
+	entries, _ := os.ReadDir(dir)
+	if len(entries) != 1 {
+		t.Fatalf("temporary files left behind: %v", entries)
+	}
+}
+
+func TestWatcherScheduleDebounces(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.toml")
+	if err := os.WriteFile(path, []byte(twoOverlays), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	watcher, err := NewWatcher(path, parseConfig(t, twoOverlays))
+	if err != nil {
+		t.Fatal(err)
+	}
+	watcher.SaveDelay = 50 * time.Millisecond
+
+	next := parseConfig(t, twoOverlays)
+	for x := range 5 {
+		next.Overlays[0].ImagePosition.X = 100 + x
+		watcher.Schedule(next)
+	}
+
+	if data, _ := os.ReadFile(path); string(data) != twoOverlays {
+		t.Fatalf("file written before the save delay:\n%s", data)
+	}
+
+	want := strings.Replace(twoOverlays, "x = 1 # left edge", "x = 104 # left edge", 1)
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		data, _ := os.ReadFile(path)
+		if string(data) == want {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("debounced save wrote:\n%s\nwant:\n%s", data, want)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
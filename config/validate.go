@@ -0,0 +1,514 @@
+package config
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	_ "image/gif"
+	_ "image/jpeg"
+	_ "image/png"
+	"os"
+	"reflect"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/fluffy-melli/visualio/images"
+	units "github.com/fluffy-melli/visualio/strings"
+	"github.com/pelletier/go-toml"
+	_ "golang.org/x/image/webp"
+)
+
+type Problem struct {
+	Line    int
+	Column  int
+	Key     string
+	Message string
+}
+
+func (p Problem) String() string {
+	if p.Key == "" {
+		return fmt.Sprintf("%d:%d: %s", p.Line, p.Column, p.Message)
+	}
+	return fmt.Sprintf("%d:%d: %s: %s", p.Line, p.Column, p.Key, p.Message)
+}
+
+type ValidationError struct {
+	Path     string
+	Problems []Problem
+}
+
+func (e *ValidationError) Error() string {
+	lines := make([]string, len(e.Problems))
+	for i, problem := range e.Problems {
+		lines[i] = e.Path + ":" + problem.String()
+	}
+	return strings.Join(lines, "\n")
+}
+
+var transformKeys = map[string][]string{
+	"resize":      {},
+	"crop":        {"x", "y", "width", "height"},
+	"trim":        {"threshold"},
+	"flip":        {"horizontal", "vertical"},
+	"rotate":      {"angle"},
+	"pad":         {"top", "right", "bottom", "left"},
+	"opacity":     {"amount"},
+	"brightness":  {"amount"},
+	"contrast":    {"amount"},
+	"saturation":  {"amount"},
+	"grayscale":   {"amount"},
+	"invert":      {"amount"},
+	"hue-rotate":  {"angle"},
+	"tint":        {"color", "amount"},
+	"color-key":   {"color", "tolerance", "feather"},
+	"outline":     {"width", "color"},
+	"drop-shadow": {"offset-x", "offset-y", "blur", "color"},
+	"glow":        {"radius", "amount", "color"},
+}
+
+type validator struct {
+	problems []Problem
+	legacy   int
+}
+
+func Validate(configPath string) error {
+	data, err := os.ReadFile(configPath)
+	if err != nil {
+		return err
+	}
+
+	_, _, err = validate(configPath, data)
+	return err
+}
+
+func validate(configPath string, data []byte) (*Config, []migration, error) {
+	v := &validator{}
+
+	tree, err := toml.LoadBytes(data)
+	if err != nil {
+		v.problems = append(v.problems, syntaxProblem(err))
+		return nil, nil, v.result(configPath)
+	}
+
+	if version, err := schemaVersion(tree); err == nil && version < CurrentSchema && hasImageSource(tree) {
+		v.legacy = version
+	}
+
+	applied, err := migrate(tree)
+	if err != nil {
+		v.add(v.position(tree, "schema-version"), "schema-version", "%v", err)
+		return nil, nil, v.result(configPath)
+	}
+
+	v.checkTable(tree, reflect.TypeOf(Config{}), "")
+
+	var config Config
+	if err := tree.Unmarshal(&config); err != nil {
+		if len(v.problems) == 0 {
+			v.add(tree.Position(), "", "%v", err)
+		}
+		return nil, nil, v.result(configPath)
+	}
+
+	v.checkConfig(tree, &config)
+	if len(v.problems) > 0 {
+		return nil, nil, v.result(configPath)
+	}
+
+	return &config, applied, nil
+}
+
+func syntaxProblem(err error) Problem {
+	var problem Problem
+	message := err.Error()
+
+	if _, scanErr := fmt.Sscanf(message, "(%d, %d):", &problem.Line, &problem.Column); scanErr == nil {
+		if _, rest, ok := strings.Cut(message, "):"); ok {
+			message = strings.TrimSpace(rest)
+		}
+	}
+
+	problem.Message = message
+	return problem
+}
+
+func (v *validator) result(configPath string) error {
+	sort.SliceStable(v.problems, func(i, j int) bool {
+		if v.problems[i].Line != v.problems[j].Line {
+			return v.problems[i].Line < v.problems[j].Line
+		}
+		return v.problems[i].Column < v.problems[j].Column
+	})
+	return &ValidationError{Path: configPath, Problems: v.problems}
+}
+
+func (v *validator) add(position toml.Position, key, format string, args ...any) {
+	v.problems = append(v.problems, Problem{
+		Line:    position.Line,
+		Column:  position.Col,
+		Key:     v.writtenKey(key),
+		Message: fmt.Sprintf(format, args...),
+	})
+}
+
+func (v *validator) writtenKey(key string) string {
+	if v.legacy == 0 || !strings.HasPrefix(key, "overlay[") {
+		return key
+	}
+
+	end := strings.IndexByte(key, ']')
+	if end < 0 {
+		return key
+	}
+	index, err := strconv.Atoi(key[len("overlay["):end])
+	if err != nil {
+		return key
+	}
+
+	if index > 1 {
+		return fmt.Sprintf("overlay[%d]%s", index-1, key[end+1:])
+	}
+	key = strings.TrimPrefix(key[end+1:], ".")
+	if v.legacy == 1 && key == "image.source" {
+		return "image"
+	}
+	return key
+}
+
+func joinKey(prefix, key string) string {
+	if prefix == "" {
+		return key
+	}
+	return prefix + "." + key
+}
+
+func tomlFields(t reflect.Type) map[string]reflect.Type {
+	fields := make(map[string]reflect.Type)
+	for i := range t.NumField() {
+		field := t.Field(i)
+		name, _, _ := strings.Cut(field.Tag.Get("toml"), ",")
+		if name != "" && name != "-" {
+			fields[name] = field.Type
+		}
+	}
+	return fields
+}
+
+func (v *validator) checkTable(tree *toml.Tree, t reflect.Type, prefix string) {
+	fields := tomlFields(t)
+
+	for _, key := range tree.Keys() {
+		path := joinKey(prefix, key)
+		position := tree.GetPosition(key)
+
+		field, ok := fields[key]
+		if !ok {
+			if suggestion := closestKey(key, fields); suggestion != "" {
+				v.add(position, path, "unknown key, did you mean %q?", suggestion)
+			} else {
+				v.add(position, path, "unknown key")
+			}
+			continue
+		}
+
+		v.checkValue(tree.Get(key), field, path, position)
+	}
+}
+
+func (v *validator) checkValue(value any, t reflect.Type, path string, position toml.Position) {
+	if t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	switch t.Kind() {
+	case reflect.Struct:
+		tree, ok := value.(*toml.Tree)
+		if !ok {
+			v.add(position, path, "expected a table")
+			return
+		}
+		v.checkTable(tree, t, path)
+	case reflect.Slice:
+		trees, ok := value.([]*toml.Tree)
+		if !ok {
+			v.add(position, path, "expected an array of tables")
+			return
+		}
+		for i, tree := range trees {
+			v.checkTable(tree, t.Elem(), fmt.Sprintf("%s[%d]", path, i+1))
+		}
+	case reflect.String:
+		if _, ok := value.(string); !ok {
+			v.add(position, path, "expected a string, got %v", value)
+		}
+	case reflect.Int:
+		if _, ok := value.(int64); !ok {
+			v.add(position, path, "expected an integer, got %v", value)
+		}
+	case reflect.Float64:
+		switch value := value.(type) {
+		case float64:
+		case int64:
+			v.add(position, path, "expected a float, write %d.0 instead of %d", value, value)
+		default:
+			v.add(position, path, "expected a float, got %v", value)
+		}
+	case reflect.Bool:
+		if _, ok := value.(bool); !ok {
+			v.add(position, path, "expected true or false, got %v", value)
+		}
+	}
+}
+
+func closestKey(key string, fields map[string]reflect.Type) string {
+	normalized := strings.ReplaceAll(key, "_", "-")
+	best, bestDistance := "", 3
+
+	for name := range fields {
+		if name == normalized {
+			return name
+		}
+		if distance := editDistance(key, name); distance < bestDistance || (distance == bestDistance && name < best) {
+			best, bestDistance = name, distance
+		}
+	}
+	return best
+}
+
+func editDistance(a, b string) int {
+	previous := make([]int, len(b)+1)
+	current := make([]int, len(b)+1)
+	for j := range previous {
+		previous[j] = j
+	}
+
+	for i := 1; i <= len(a); i++ {
+		current[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			current[j] = min(previous[j]+1, current[j-1]+1, previous[j-1]+cost)
+		}
+		previous, current = current, previous
+	}
+	return previous[len(b)]
+}
+
+func (v *validator) position(tree *toml.Tree, keys ...string) toml.Position {
+	position := tree.Position()
+	for i := range keys {
+		if !tree.HasPath(keys[:i+1]) {
+			break
+		}
+		position = tree.GetPositionPath(keys[:i+1])
+	}
+	return position
+}
+
+func (v *validator) checkConfig(tree *toml.Tree, config *Config) {
+	if config.Cache.MemoryBudget < 0 {
+		v.add(v.position(tree, "cache", "memory-budget"), "cache.memory-budget", "must not be negative")
+	}
+	if config.Cache.FrameMemoryLimit < 0 {
+		v.add(v.position(tree, "cache", "frame-memory-limit"), "cache.frame-memory-limit", "must not be negative")
+	}
+
+	if config.Image.Source != "" {
+		v.checkOverlay(tree, "", config.primaryOverlay())
+	}
+
+	if len(config.Overlays) > 0 {
+		trees, _ := tree.Get("overlay").([]*toml.Tree)
+		for i, overlay := range config.Overlays {
+			v.checkOverlay(trees[i], fmt.Sprintf("overlay[%d]", i+1), overlay)
+		}
+	}
+
+	if config.Image.Source == "" && len(config.Overlays) == 0 {
+		v.add(v.position(tree, "image", "source"), "image.source", "no image to show, set image.source or add an [[overlay]]")
+	}
+}
+
+func (v *validator) checkOverlay(tree *toml.Tree, prefix string, overlay Overlay) {
+	key := func(keys ...string) (toml.Position, string) {
+		return v.position(tree, keys...), joinKey(prefix, strings.Join(keys, "."))
+	}
+
+	if position, path := key("image", "source"); overlay.Image.Source == "" {
+		v.add(position, path, "is required")
+	} else if err := checkImage(overlay.Image.Source); err != nil {
+		v.add(position, path, "%v", err)
+	}
+
+	resize := overlay.ImageResize
+	if _, err := images.ParseSizeMode(resize.Mode); err != nil {
+		position, path := key("image-resize", "mode")
+		v.add(position, path, "%v", err)
+	}
+	if _, err := images.ParseFilter(resize.Filter); err != nil {
+		position, path := key("image-resize", "filter")
+		v.add(position, path, "%v", err)
+	}
+	for _, dimension := range []struct{ name, value string }{{"width", resize.Width}, {"height", resize.Height}} {
+		if dimension.value == "" {
+			continue
+		}
+		position, path := key("image-resize", dimension.name)
+		if parsed, err := units.ParseDimension(dimension.value); err != nil {
+			v.add(position, path, "%v", err)
+		} else if !canBePositive(parsed) {
+			v.add(position, path, "must resolve to a positive size, got %q", dimension.value)
+		}
+	}
+
+	animation := overlay.Animation
+	if _, err := images.ParsePlaybackMode(animation.Mode); err != nil {
+		position, path := key("animation", "mode")
+		v.add(position, path, "%v", err)
+	}
+	if _, err := images.ParseDelayPolicy(animation.DelayPolicy); err != nil {
+		position, path := key("animation", "delay-policy")
+		v.add(position, path, "%v", err)
+	}
+	if animation.Start < 0 {
+		position, path := key("animation", "start")
+		v.add(position, path, "must not be negative")
+	}
+	if animation.End < 0 {
+		position, path := key("animation", "end")
+		v.add(position, path, "must not be negative")
+	} else if animation.End > 0 && animation.End <= animation.Start {
+		position, path := key("animation", "end")
+		v.add(position, path, "must be greater than start (%d), or 0 for the last frame", animation.Start)
+	}
+	if animation.Speed < 0 {
+		position, path := key("animation", "speed")
+		v.add(position, path, "must not be negative")
+	}
+	if animation.MinDelay < 0 {
+		position, path := key("animation", "min-delay")
+		v.add(position, path, "must not be negative")
+	}
+
+	trees, _ := tree.Get("transform").([]*toml.Tree)
+	resized := false
+	for i, transform := range overlay.Transforms {
+		if i >= len(trees) {
+			break
+		}
+		if transform.Type == "resize" {
+			if resized {
+				v.add(trees[i].GetPosition("type"), fmt.Sprintf("%s[%d].type", joinKey(prefix, "transform"), i+1), "resize can only appear once")
+			}
+			resized = true
+		}
+		v.checkTransform(trees[i], fmt.Sprintf("%s[%d]", joinKey(prefix, "transform"), i+1), transform)
+	}
+}
+
+func canBePositive(d units.Dimension) bool {
+	return slices.ContainsFunc(d.Terms, func(term units.NumberUnit) bool {
+		return term.Value > 0
+	})
+}
+
+func (v *validator) checkTransform(tree *toml.Tree, prefix string, t Transform) {
+	key := func(name string) (toml.Position, string) {
+		return v.position(tree, name), joinKey(prefix, name)
+	}
+
+	allowed, ok := transformKeys[t.Type]
+	if !ok {
+		position, path := key("type")
+		if t.Type == "" {
+			v.add(position, path, "is required")
+		} else {
+			v.add(position, path, "unknown transform type %q", t.Type)
+		}
+		return
+	}
+
+	for _, name := range tree.Keys() {
+		if name != "type" && !slices.Contains(allowed, name) {
+			position, path := key(name)
+			v.add(position, path, "does not apply to %s", t.Type)
+		}
+	}
+
+	if t.Amount != nil && *t.Amount < 0 {
+		position, path := key("amount")
+		v.add(position, path, "must not be negative")
+	}
+
+	switch t.Type {
+	case "crop":
+		if t.Width <= 0 || t.Height <= 0 {
+			position, path := key("width")
+			v.add(position, path, "crop needs a positive width and height")
+		}
+	case "trim":
+		if t.Threshold < 0 || t.Threshold > 255 {
+			position, path := key("threshold")
+			v.add(position, path, "must be between 0 and 255")
+		}
+	case "tint":
+		if t.Color == "" {
+			position, path := key("color")
+			v.add(position, path, "is required")
+		}
+	case "color-key":
+		if t.Tolerance < 0 {
+			position, path := key("tolerance")
+			v.add(position, path, "must not be negative")
+		}
+		if t.Feather < 0 {
+			position, path := key("feather")
+			v.add(position, path, "must not be negative")
+		}
+	case "outline":
+		if t.Width <= 0 {
+			position, path := key("width")
+			v.add(position, path, "must be positive")
+		}
+	case "drop-shadow":
+		if t.Blur < 0 {
+			position, path := key("blur")
+			v.add(position, path, "must not be negative")
+		}
+	case "glow":
+		if t.Radius <= 0 {
+			position, path := key("radius")
+			v.add(position, path, "must be positive")
+		}
+	}
+
+	if t.Color != "" && !(t.Type == "color-key" && t.Color == "auto") {
+		if _, err := images.ParseColor(t.Color); err != nil {
+			position, path := key("color")
+			v.add(position, path, "%v", err)
+		}
+	}
+}
+
+func checkImage(source string) error {
+	data, err := os.ReadFile(source)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return fmt.Errorf("file %q does not exist", source)
+		}
+		return err
+	}
+
+	if images.IsAPNG(data) || images.IsAnimatedWebP(data) {
+		return nil
+	}
+
+	if _, _, err := image.DecodeConfig(bytes.NewReader(data)); err != nil {
+		return fmt.Errorf("cannot decode %q: %w", source, err)
+	}
+	return nil
+}
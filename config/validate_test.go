@@ -0,0 +1,143 @@
+package config
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/gif"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestValidateProblems(t *testing.T) {
+	const overlay = "[[overlay]]\n  [overlay.image]\n    source = \"a.png\"\n"
+
+	tests := []struct {
+		name  string
+		input string
+		want  []string
+	}{
+		{
+			name:  "typo suggestion",
+			input: overlay + "  [overlay.animaton]\n    sped = 2.0\n",
+			want:  []string{`4:3: overlay[1].animaton: unknown key, did you mean "animation"?`},
+		},
+		{
+			name:  "underscore suggestion",
+			input: "[cache]\n  memory_budget = 10\n" + overlay,
+			want:  []string{`2:3: cache.memory_budget: unknown key, did you mean "memory-budget"?`},
+		},
+		{
+			name:  "unknown key without a suggestion",
+			input: "[[overlay]]\n  wallpaper = true\n  [overlay.image]\n    source = \"a.png\"\n",
+			want:  []string{"2:3: overlay[1].wallpaper: unknown key"},
+		},
+		{
+			name:  "integer for a float",
+			input: overlay + "  [overlay.animation]\n    speed = 2\n",
+			want:  []string{"5:5: overlay[1].animation.speed: expected a float, write 2.0 instead of 2"},
+		},
+		{
+			name:  "string for an integer",
+			input: "[cache]\n  memory-budget = \"1GB\"\n" + overlay,
+			want:  []string{"2:3: cache.memory-budget: expected an integer, got 1GB"},
+		},
+		{
+			name:  "value for a table",
+			input: "app = 1\n" + overlay,
+			want:  []string{"1:1: app: expected a table"},
+		},
+		{
+			name:  "negative values",
+			input: "[cache]\n  memory-budget = -1\n" + overlay + "  [overlay.animation]\n    start = -2\n    speed = -1.0\n",
+			want: []string{
+				"2:3: cache.memory-budget: must not be negative",
+				"7:5: overlay[1].animation.start: must not be negative",
+				"8:5: overlay[1].animation.speed: must not be negative",
+			},
+		},
+		{
+			name:  "end before start",
+			input: overlay + "  [overlay.animation]\n    start = 4\n    end = 2\n",
+			want:  []string{"6:5: overlay[1].animation.end: must be greater than start (4), or 0 for the last frame"},
+		},
+		{
+			name:  "size that cannot be positive",
+			input: overlay + "  [overlay.image-resize]\n    width = \"0px\"\n",
+			want:  []string{`5:5: overlay[1].image-resize.width: must resolve to a positive size, got "0px"`},
+		},
+		{
+			name:  "trim threshold out of range",
+			input: overlay + "  [[overlay.transform]]\n    type = \"trim\"\n    threshold = 300\n",
+			want:  []string{"6:5: overlay[1].transform[1].threshold: must be between 0 and 255"},
+		},
+		{
+			name:  "key that does not apply",
+			input: overlay + "  [[overlay.transform]]\n    type = \"flip\"\n    angle = 90.0\n",
+			want:  []string{"6:5: overlay[1].transform[1].angle: does not apply to flip"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			writeTestImage(t, filepath.Join(dir, "a.png"))
+			t.Chdir(dir)
+
+			_, _, err := validate("config.toml", []byte(tt.input))
+			validation, ok := err.(*ValidationError)
+			if !ok {
+				t.Fatalf("err = %v, want a validation error", err)
+			}
+
+			got := make([]string, len(validation.Problems))
+			for i, problem := range validation.Problems {
+				got[i] = problem.String()
+			}
+			if strings.Join(got, "\n") != strings.Join(tt.want, "\n") {
+				t.Fatalf("problems:\n%s\nwant:\n%s", strings.Join(got, "\n"), strings.Join(tt.want, "\n"))
+			}
+		})
+	}
+}
+
+func TestCheckImage(t *testing.T) {
+	dir := t.TempDir()
+
+	writeTestImage(t, filepath.Join(dir, "still.png"))
+
+	var buffer bytes.Buffer
+	if err := gif.Encode(&buffer, image.NewPaletted(image.Rect(0, 0, 1, 1), color.Palette{color.Black}), nil); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "still.gif"), buffer.Bytes(), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	webp, err := os.ReadFile(filepath.Join("..", "images", "testdata", "lossy.webp"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "still.webp"), webp, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := os.WriteFile(filepath.Join(dir, "broken.png"), []byte("not an image"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, name := range []string{"still.png", "still.gif", "still.webp"} {
+		if err := checkImage(filepath.Join(dir, name)); err != nil {
+			t.Errorf("%s: %v", name, err)
+		}
+	}
+
+	if err := checkImage(filepath.Join(dir, "broken.png")); err == nil || !strings.Contains(err.Error(), "cannot decode") {
+		t.Errorf("broken.png: err = %v, want a decode error", err)
+	}
+	if err := checkImage(filepath.Join(dir, "missing.png")); err == nil || !strings.Contains(err.Error(), "does not exist") {
+		t.Errorf("missing.png: err = %v, want a missing file error", err)
+	}
+}
@@ -0,0 +1,210 @@
+package config
+
+import (
+	"context"
+	"crypto/sha256"
+	"os"
+	"sync"
+	"time"
+)
+
+const (
+	DefaultPollInterval = time.Second
+	DefaultSaveDelay    = 500 * time.Millisecond
+)
+
+type fileStamp struct {
+	modTime time.Time
+	size    int64
+}
+
+type Watcher struct {
+	mu        sync.Mutex
+	path      string
+	stamp     fileStamp
+	sum       [sha256.Size]byte
+	current   *Config
+	pending   *Config
+	saveTimer *time.Timer
+	Interval  time.Duration
+	SaveDelay time.Duration
+	OnChange  func(previous, current *Config)
+	OnError   func(error)
+}
+
+func NewWatcher(configPath string, current *Config) (*Watcher, error) {
+	w := &Watcher{
+		path:      configPath,
+		current:   current.Clone(),
+		Interval:  DefaultPollInterval,
+		SaveDelay: DefaultSaveDelay,
+	}
+
+	data, err := os.ReadFile(configPath)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := w.remember(data); err != nil {
+		return nil, err
+	}
+	return w, nil
+}
+
+func (w *Watcher) remember(data []byte) error {
+	info, err := os.Stat(w.path)
+	if err != nil {
+		return err
+	}
+
+	w.stamp = fileStamp{modTime: info.ModTime(), size: info.Size()}
+	w.sum = sha256.Sum256(data)
+	return nil
+}
+
+func (w *Watcher) Run(ctx context.Context) {
+	ticker := time.NewTicker(w.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			w.Poll()
+		}
+	}
+}
+
+func (w *Watcher) Poll() {
+	w.mu.Lock()
+
+	info, err := os.Stat(w.path)
+	if err != nil {
+		w.mu.Unlock()
+		if !os.IsNotExist(err) {
+			w.report(err)
+		}
+		return
+	}
+
+	stamp := fileStamp{modTime: info.ModTime(), size: info.Size()}
+	if stamp == w.stamp {
+		w.mu.Unlock()
+		return
+	}
+
+	if w.pending != nil {
+		pending := w.pending
+		w.pending = nil
+		if err := w.save(pending); err != nil {
+			w.mu.Unlock()
+			w.report(err)
+			return
+		}
+		if info, err = os.Stat(w.path); err != nil {
+			w.mu.Unlock()
+			w.report(err)
+			return
+		}
+		stamp = fileStamp{modTime: info.ModTime(), size: info.Size()}
+	}
+
+	data, err := os.ReadFile(w.path)
+	if err != nil {
+		w.mu.Unlock()
+		w.report(err)
+		return
+	}
+
+	w.stamp = stamp
+	sum := sha256.Sum256(data)
+	if sum == w.sum {
+		w.mu.Unlock()
+		return
+	}
+	w.sum = sum
+
+	config, _, err := validate(w.path, data)
+	if err != nil {
+		w.mu.Unlock()
+		w.report(err)
+		return
+	}
+
+	previous := w.current
+	w.current = config
+	w.mu.Unlock()
+
+	if w.OnChange != nil {
+		w.OnChange(previous.Clone(), config.Clone())
+	}
+}
+
+func (w *Watcher) Save(config *Config) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.pending = nil
+	if w.saveTimer != nil {
+		w.saveTimer.Stop()
+	}
+	return w.save(config)
+}
+
+func (w *Watcher) save(config *Config) error {
+	data, err := os.ReadFile(w.path)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	external := sha256.Sum256(data) != w.sum
+
+	encoded, err := encode(data, w.current, config)
+	if err != nil {
+		return err
+	}
+
+	if err := writeFileAtomic(w.path, encoded); err != nil {
+		return err
+	}
+
+	w.current = config.Clone()
+	if external {
+		return nil
+	}
+	return w.remember(encoded)
+}
+
+func (w *Watcher) Schedule(config *Config) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.pending = config.Clone()
+	if w.saveTimer != nil {
+		w.saveTimer.Stop()
+	}
+	w.saveTimer = time.AfterFunc(w.SaveDelay, func() {
+		if err := w.Flush(); err != nil {
+			w.report(err)
+		}
+	})
+}
+
+func (w *Watcher) Flush() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.pending == nil {
+		return nil
+	}
+
+	pending := w.pending
+	w.pending = nil
+	return w.save(pending)
+}
+
+func (w *Watcher) report(err error) {
+	if w.OnError != nil {
+		w.OnError(err)
+	}
+}
@@ -0,0 +1,132 @@
+package config
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+type watchEvents struct {
+	changes [][2]*Config
+	errors  []error
+}
+
+func newTestWatcher(t *testing.T) (string, *Watcher, *watchEvents) {
+	t.Helper()
+
+	dir := t.TempDir()
+	for _, image := range []string{"a.png", "b.png"} {
+		writeTestImage(t, filepath.Join(dir, image))
+	}
+	t.Chdir(dir)
+
+	path := filepath.Join(dir, "config.toml")
+	if err := os.WriteFile(path, []byte(twoOverlays), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	watcher, err := NewWatcher(path, parseConfig(t, twoOverlays))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	events := &watchEvents{}
+	watcher.OnChange = func(previous, current *Config) {
+		events.changes = append(events.changes, [2]*Config{previous, current})
+	}
+	watcher.OnError = func(err error) {
+		events.errors = append(events.errors, err)
+	}
+	return path, watcher, events
+}
+
+func TestWatcherIgnoresOwnSave(t *testing.T) {
+	path, watcher, events := newTestWatcher(t)
+
+	next := parseConfig(t, twoOverlays)
+	next.Overlays[1].Name = "renamed"
+	if err := watcher.Save(next); err != nil {
+		t.Fatal(err)
+	}
+	watcher.Poll()
+	watcher.Poll()
+
+	if len(events.changes) != 0 || len(events.errors) != 0 {
+		t.Fatalf("own save reported %d changes and errors %v", len(events.changes), events.errors)
+	}
+	if data, _ := os.ReadFile(path); !strings.Contains(string(data), `name = "renamed"`) {
+		t.Fatalf("save did not reach the file:\n%s", data)
+	}
+}
+
+func TestWatcherReloadsExternalEdit(t *testing.T) {
+	path, watcher, events := newTestWatcher(t)
+
+	edited := strings.Replace(twoOverlays, `name = "b"`, `name = "edited"`, 1)
+	if err := os.WriteFile(path, []byte(edited), 0644); err != nil {
+		t.Fatal(err)
+	}
+	watcher.Poll()
+	watcher.Poll()
+
+	if len(events.errors) != 0 {
+		t.Fatal(events.errors)
+	}
+	if len(events.changes) != 1 {
+		t.Fatalf("external edit reported %d changes, want 1", len(events.changes))
+	}
+	if previous, current := events.changes[0][0], events.changes[0][1]; previous.Overlays[1].Name != "b" || current.Overlays[1].Name != "edited" {
+		t.Fatalf("change = %q -> %q, want b -> edited", previous.Overlays[1].Name, current.Overlays[1].Name)
+	}
+}
+
+func TestWatcherKeepsLastGoodConfig(t *testing.T) {
+	path, watcher, events := newTestWatcher(t)
+
+	if err := os.WriteFile(path, []byte(twoOverlays+"  wallpaper = true\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	watcher.Poll()
+	watcher.Poll()
+
+	var validation *ValidationError
+	if len(events.errors) != 1 || !errors.As(events.errors[0], &validation) {
+		t.Fatalf("errors = %v, want one validation error", events.errors)
+	}
+	if len(events.changes) != 0 {
+		t.Fatal("invalid edit was applied")
+	}
+
+	edited := strings.Replace(twoOverlays, `name = "b"`, `name = "fixed"`, 1)
+	if err := os.WriteFile(path, []byte(edited), 0644); err != nil {
+		t.Fatal(err)
+	}
+	watcher.Poll()
+
+	if len(events.changes) != 1 || events.changes[0][0].Overlays[1].Name != "b" {
+		t.Fatalf("changes = %d, want one change from the last good config", len(events.changes))
+	}
+}
+
+func TestWatcherDetectsRenameReplace(t *testing.T) {
+	path, watcher, events := newTestWatcher(t)
+
+	temp := path + ".swp"
+	edited := strings.Replace(twoOverlays, `name = "b"`, `name = "replaced"`, 1)
+	if err := os.WriteFile(temp, []byte(edited), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Rename(temp, path); err != nil {
+		t.Fatal(err)
+	}
+	watcher.Poll()
+
+	if len(events.errors) != 0 {
+		t.Fatal(events.errors)
+	}
+	if len(events.changes) != 1 || events.changes[0][1].Overlays[1].Name != "replaced" {
+		t.Fatalf("rename over the config reported %d changes, want 1", len(events.changes))
+	}
+}
@@ -1,12 +1,16 @@
+//go:build windows
+
 package constants
 
 import "golang.org/x/sys/windows"
 
 const (
 	WM_DESTROY     = 0x0002
+	WM_CLOSE       = 0x0010
 	WM_PAINT       = 0x000F
 	WM_KEYDOWN     = 0x0100
 	WM_RBUTTONDOWN = 0x0204
+	WM_APP         = 0x8000
 
 	WS_POPUP          = 0x80000000
 	WS_VISIBLE        = 0x10000000
@@ -22,12 +26,13 @@ const (
 	SW_SHOW   = 5
 	VK_ESCAPE = 0x1B
 
-	LWA_ALPHA    = 0x00000002
-	LWA_COLORKEY = 0x00000001
+	LWA_ALPHA = 0x00000002
 
-	SRCCOPY = 0x00CC0020
+	ULW_ALPHA    = 0x00000002
+	AC_SRC_OVER  = 0x00
+	AC_SRC_ALPHA = 0x01
 
-	TRANSPARENT_COLOR = 0x00000000
+	SRCCOPY = 0x00CC0020
 )
 
 var (
@@ -42,14 +47,17 @@ var (
 	ProcTranslateMessage           = user32.NewProc("TranslateMessage")
 	ProcDispatchMessage            = user32.NewProc("DispatchMessageW")
 	ProcPostQuitMessage            = user32.NewProc("PostQuitMessage")
+	ProcPostMessage                = user32.NewProc("PostMessageW")
 	ProcShowWindow                 = user32.NewProc("ShowWindow")
 	ProcUpdateWindow               = user32.NewProc("UpdateWindow")
 	ProcGetWindowDC                = user32.NewProc("GetWindowDC")
 	ProcReleaseDC                  = user32.NewProc("ReleaseDC")
 	ProcGetModuleHandle            = kernel32.NewProc("GetModuleHandleW")
 	ProcSetLayeredWindowAttributes = user32.NewProc("SetLayeredWindowAttributes")
+	ProcUpdateLayeredWindow        = user32.NewProc("UpdateLayeredWindow")
 	ProcSetWindowPos               = user32.NewProc("SetWindowPos")
 	ProcGetSystemMetrics           = user32.NewProc("GetSystemMetrics")
+	ProcGetDpiForSystem            = user32.NewProc("GetDpiForSystem")
 	ProcInvalidateRect             = user32.NewProc("InvalidateRect")
 	ProcCreateSolidBrush           = gdi32.NewProc("CreateSolidBrush")
 
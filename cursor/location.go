@@ -2,10 +2,9 @@ package cursor
 
 import (
 	"context"
+	"image"
 	"time"
-	"unsafe"
 
-	"github.com/fluffy-melli/visualio/constants"
 	"github.com/fluffy-melli/visualio/graphics"
 )
 
@@ -14,15 +13,6 @@ type Location struct {
 	Y int32
 }
 
-func Position() (Location, error) {
-	var pt Location
-	ret, _, err := constants.ProcGetCursorPos.Call(uintptr(unsafe.Pointer(&pt)))
-	if ret == 0 {
-		return pt, err
-	}
-	return pt, nil
-}
-
 func PositionReader(ctx context.Context, out chan<- Location) func(s *graphics.Render) {
 	return func(s *graphics.Render) {
 		ticker := time.NewTicker(100 * time.Millisecond)
@@ -32,11 +22,11 @@ func PositionReader(ctx context.Context, out chan<- Location) func(s *graphics.R
 			case <-ctx.Done():
 				return
 			case <-ticker.C:
-				pos, err := Position()
+				point, err := s.CursorPosition()
 				if err != nil {
 					panic(err)
 				}
-				out <- pos
+				out <- Location{X: int32(point.X), Y: int32(point.Y)}
 			}
 		}
 	}
@@ -51,18 +41,10 @@ func DeltaHandler(ctx context.Context, in <-chan Location) func(s *graphics.Rend
 				return
 			case pos := <-in:
 				if pos != last {
-					img := s.CurrentImage()
-					if img != nil {
-						bounds := img.Bounds()
-						minX, minY := s.AX, s.AY
-						maxX, maxY := minX+bounds.Dx(), minY+bounds.Dy()
-						s.IsInside = int(pos.X) >= minX && int(pos.X) < maxX &&
-							int(pos.Y) >= minY && int(pos.Y) < maxY
-					}
-					if s.IsClicked {
+					s.SetInside(s.Contains(image.Pt(int(pos.X), int(pos.Y))))
+					if s.IsClicked() {
 						dx, dy := int(pos.X-last.X), int(pos.Y-last.Y)
-						s.AX += dx
-						s.AY += dy
+						s.Move(dx, dy)
 					}
 					last = pos
 				}
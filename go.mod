@@ -5,5 +5,6 @@ go 1.24.2
 require (
 	github.com/gonutz/d3d9 v1.2.4
 	github.com/pelletier/go-toml v1.9.5
+	golang.org/x/image v0.29.0
 	golang.org/x/sys v0.34.0
 )
@@ -0,0 +1,59 @@
+package graphics
+
+import "image"
+
+type Texture interface {
+	Size() image.Point
+	Release()
+}
+
+type EventKind int
+
+const (
+	EventPaint EventKind = iota
+	EventKeyDown
+	EventMouseDown
+	EventMouseUp
+	EventClose
+	EventCall
+)
+
+type MouseButton int
+
+const (
+	ButtonLeft MouseButton = iota
+	ButtonMiddle
+	ButtonRight
+)
+
+type Key int
+
+const KeyEscape Key = 0x1B
+
+type Event struct {
+	Kind   EventKind
+	Button MouseButton
+	Key    Key
+	Call   func()
+}
+
+type Layer struct {
+	Texture Texture
+	At      image.Point
+}
+
+type Backend interface {
+	CreateSurface(title string) error
+	ScreenSize() (int, int)
+	DPI() int
+	CursorPosition() (image.Point, error)
+	Upload(img image.Image) (Texture, error)
+	Present(layers []Layer)
+	Clear()
+	Invalidate()
+	Post(fn func())
+	Run(handle func(Event)) error
+	Quit()
+	Close()
+	Release()
+}
@@ -0,0 +1,135 @@
+package graphics
+
+import (
+	"container/list"
+	"image"
+)
+
+const DefaultCacheBudget = 256 << 20
+
+type frameKey struct {
+	frame     int
+	processor string
+	width     int
+	height    int
+	dpi       int
+}
+
+type cachedFrame struct {
+	key     frameKey
+	image   image.Image
+	texture Texture
+	size    int64
+}
+
+type frameCache struct {
+	budget  int64
+	used    int64
+	entries map[frameKey]*list.Element
+	order   *list.List
+	retired []Texture
+}
+
+func newFrameCache(budget int64) *frameCache {
+	if budget <= 0 {
+		budget = DefaultCacheBudget
+	}
+
+	return &frameCache{
+		budget:  budget,
+		entries: make(map[frameKey]*list.Element),
+		order:   list.New(),
+	}
+}
+
+func (c *frameCache) get(key frameKey) *cachedFrame {
+	element, ok := c.entries[key]
+	if !ok {
+		return nil
+	}
+
+	c.order.MoveToFront(element)
+	return element.Value.(*cachedFrame)
+}
+
+func (c *frameCache) put(key frameKey, img image.Image) *cachedFrame {
+	if element, ok := c.entries[key]; ok {
+		c.remove(element)
+	}
+
+	entry := &cachedFrame{
+		key:   key,
+		image: img,
+		size:  imageSize(img),
+	}
+
+	c.entries[key] = c.order.PushFront(entry)
+	c.used += entry.size
+	c.evict()
+
+	return entry
+}
+
+func (c *frameCache) attachTexture(entry *cachedFrame, texture Texture) {
+	if entry.texture != nil {
+		c.retired = append(c.retired, entry.texture)
+	}
+	entry.texture = texture
+
+	size := imageSize(entry.image)
+	if texture != nil {
+		size *= 2
+	}
+
+	c.used += size - entry.size
+	entry.size = size
+	c.evict()
+}
+
+func (c *frameCache) evict() {
+	for c.used > c.budget && c.order.Len() > 1 {
+		c.remove(c.order.Back())
+	}
+}
+
+func (c *frameCache) remove(element *list.Element) {
+	entry := element.Value.(*cachedFrame)
+	if entry.texture != nil {
+		c.retired = append(c.retired, entry.texture)
+		entry.texture = nil
+	}
+
+	c.order.Remove(element)
+	delete(c.entries, entry.key)
+	c.used -= entry.size
+}
+
+func (c *frameCache) clear() {
+	for c.order.Len() > 0 {
+		c.remove(c.order.Back())
+	}
+}
+
+func (c *frameCache) releaseTextures() {
+	for _, texture := range c.retired {
+		texture.Release()
+	}
+	c.retired = nil
+}
+
+func (c *frameCache) setBudget(budget int64) {
+	if budget <= 0 {
+		budget = DefaultCacheBudget
+	}
+
+	c.budget = budget
+	c.evict()
+}
+
+func imageSize(img image.Image) int64 {
+	if img == nil {
+		return 0
+	}
+	bounds := img.Bounds()
+	return int64(bounds.Dx()) * int64(bounds.Dy()) * 4
+}
@@ -0,0 +1,107 @@
+package graphics
+
+import (
+	"image"
+	"image/color"
+)
+
+type Compositor struct {
+	Width  int
+	Height int
+	Stride int
+	Pix    []byte
+}
+
+func NewCompositor(width, height int) *Compositor {
+	c := &Compositor{}
+	c.Resize(width, height)
+	return c
+}
+
+func (c *Compositor) Resize(width, height int) {
+	width, height = max(width, 0), max(height, 0)
+	size := width * height * 4
+
+	if cap(c.Pix) < size {
+		c.Pix = make([]byte, size)
+	}
+	c.Pix = c.Pix[:size]
+	c.Width, c.Height, c.Stride = width, height, width*4
+}
+
+func (c *Compositor) Bounds() image.Rectangle {
+	return image.Rect(0, 0, c.Width, c.Height)
+}
+
+func (c *Compositor) Clear() {
+	clear(c.Pix)
+}
+
+func (c *Compositor) Draw(img image.Image, at image.Point) {
+	bounds := img.Bounds()
+	target := bounds.Sub(bounds.Min).Add(at).Intersect(c.Bounds())
+	if target.Empty() {
+		return
+	}
+
+	origin := bounds.Min.Add(target.Min.Sub(at))
+	width := target.Dx()
+
+	switch src := img.(type) {
+	case *image.RGBA:
+		for y := range target.Dy() {
+			offset := src.PixOffset(origin.X, origin.Y+y)
+			c.blendRow(target.Min.X, target.Min.Y+y, src.Pix[offset:offset+width*4], true)
+		}
+		return
+
+	case *image.NRGBA:
+		for y := range target.Dy() {
+			offset := src.PixOffset(origin.X, origin.Y+y)
+			c.blendRow(target.Min.X, target.Min.Y+y, src.Pix[offset:offset+width*4], false)
+		}
+		return
+	}
+
+	row := make([]byte, width*4)
+	for y := range target.Dy() {
+		for x := range width {
+			p := color.RGBAModel.Convert(img.At(origin.X+x, origin.Y+y)).(color.RGBA)
+			row[x*4+0], row[x*4+1], row[x*4+2], row[x*4+3] = p.R, p.G, p.B, p.A
+		}
+		c.blendRow(target.Min.X, target.Min.Y+y, row, true)
+	}
+}
+
+func (c *Compositor) blendRow(x, y int, src []byte, premultiplied bool) {
+	dst := c.Pix[y*c.Stride+x*4 : y*c.Stride+x*4+len(src)]
+
+	for i := 0; i < len(src); i += 4 {
+		a := uint32(src[i+3])
+		if a == 0 {
+			continue
+		}
+
+		r, g, b := uint32(src[i+0]), uint32(src[i+1]), uint32(src[i+2])
+		if !premultiplied {
+			r, g, b = mul255(r, a), mul255(g, a), mul255(b, a)
+		}
+
+		p := dst[i : i+4 : i+4]
+		if a == 255 {
+			p[0], p[1], p[2], p[3] = byte(b), byte(g), byte(r), 255
+			continue
+		}
+
+		inverse := 255 - a
+		p[0] = byte(min(b+mul255(uint32(p[0]), inverse), 255))
+		p[1] = byte(min(g+mul255(uint32(p[1]), inverse), 255))
+		p[2] = byte(min(r+mul255(uint32(p[2]), inverse), 255))
+		p[3] = byte(min(a+mul255(uint32(p[3]), inverse), 255))
+	}
+}
+
+func mul255(v, a uint32) uint32 {
+	t := v*a + 128
+	return (t + t>>8) >> 8
+}
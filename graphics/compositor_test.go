@@ -0,0 +1,134 @@
+package graphics
+
+import (
+	"image"
+	"image/color"
+	"testing"
+)
+
+func bgraAt(c *Compositor, x, y int) [4]byte {
+	offset := y*c.Stride + x*4
+	return [4]byte(c.Pix[offset : offset+4])
+}
+
+func fill(c *Compositor, bgra [4]byte) {
+	for i := 0; i < len(c.Pix); i += 4 {
+		copy(c.Pix[i:i+4], bgra[:])
+	}
+}
+
+func uniform(kind string, width, height int, c color.NRGBA) image.Image {
+	rect := image.Rect(0, 0, width, height)
+	switch kind {
+	case "rgba":
+		img := image.NewRGBA(rect)
+		for y := range height {
+			for x := range width {
+				img.Set(x, y, c)
+			}
+		}
+		return img
+	case "nrgba":
+		img := image.NewNRGBA(rect)
+		for y := range height {
+			for x := range width {
+				img.SetNRGBA(x, y, c)
+			}
+		}
+		return img
+	}
+
+	return image.NewPaletted(rect, color.Palette{c})
+}
+
+func TestCompositorDraw(t *testing.T) {
+	opaqueRed := [4]byte{0, 0, 255, 255}
+
+	tests := []struct {
+		name  string
+		src   color.NRGBA
+		under [4]byte
+		want  [4]byte
+	}{
+		{name: "opaque over empty", src: color.NRGBA{R: 255, A: 255}, want: [4]byte{0, 0, 255, 255}},
+		{name: "opaque over existing", src: color.NRGBA{G: 255, A: 255}, under: opaqueRed, want: [4]byte{0, 255, 0, 255}},
+		{name: "half alpha over empty", src: color.NRGBA{B: 255, A: 128}, want: [4]byte{128, 0, 0, 128}},
+		{name: "half alpha over existing", src: color.NRGBA{B: 255, A: 128}, under: opaqueRed, want: [4]byte{128, 0, 127, 255}},
+		{name: "half alpha over half alpha", src: color.NRGBA{B: 255, A: 128}, under: [4]byte{0, 0, 128, 128}, want: [4]byte{128, 0, 64, 192}},
+		{name: "transparent leaves pixel", src: color.NRGBA{R: 255}, under: opaqueRed, want: opaqueRed},
+	}
+
+	for _, kind := range []string{"rgba", "nrgba", "generic"} {
+		for _, tt := range tests {
+			t.Run(kind+"/"+tt.name, func(t *testing.T) {
+				c := NewCompositor(2, 2)
+				fill(c, tt.under)
+
+				c.Draw(uniform(kind, 2, 2, tt.src), image.Point{})
+
+				for y := range 2 {
+					for x := range 2 {
+						if got := bgraAt(c, x, y); got != tt.want {
+							t.Fatalf("pixel (%d, %d) = %v, want %v", x, y, got, tt.want)
+						}
+					}
+				}
+			})
+		}
+	}
+}
+
+func TestCompositorDrawClipped(t *testing.T) {
+	src := image.NewRGBA(image.Rect(10, 20, 14, 24))
+	for y := 20; y < 24; y++ {
+		for x := 10; x < 14; x++ {
+			src.SetRGBA(x, y, color.RGBA{R: uint8(x), G: uint8(y), A: 255})
+		}
+	}
+
+	tests := []struct {
+		name  string
+		at    image.Point
+		drawn image.Rectangle
+	}{
+		{name: "top left", at: image.Pt(-2, -3), drawn: image.Rect(0, 0, 2, 1)},
+		{name: "bottom right", at: image.Pt(4, 3), drawn: image.Rect(4, 3, 5, 4)},
+		{name: "outside", at: image.Pt(5, 0), drawn: image.Rectangle{}},
+		{name: "inside", at: image.Pt(1, 0), drawn: image.Rect(1, 0, 5, 4)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := NewCompositor(5, 4)
+			c.Draw(src, tt.at)
+
+			for y := range c.Height {
+				for x := range c.Width {
+					want := [4]byte{}
+					if image.Pt(x, y).In(tt.drawn) {
+						sx, sy := x-tt.at.X+10, y-tt.at.Y+20
+						want = [4]byte{0, byte(sy), byte(sx), 255}
+					}
+					if got := bgraAt(c, x, y); got != want {
+						t.Fatalf("pixel (%d, %d) = %v, want %v", x, y, got, want)
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestCompositorResizeReusesBuffer(t *testing.T) {
+	c := NewCompositor(8, 8)
+	pix := &c.Pix[0]
+
+	c.Resize(4, 4)
+	if &c.Pix[0] != pix || len(c.Pix) != 4*4*4 || c.Stride != 16 {
+		t.Fatal("shrinking reallocated the buffer or left a stale size")
+	}
+
+	c.Resize(-1, 3)
+	if c.Width != 0 || len(c.Pix) != 0 {
+		t.Fatalf("negative size gave %dx%d", c.Width, c.Height)
+	}
+}
@@ -1,10 +1,11 @@
+//go:build windows
+
 package graphics
 
 import (
 	"fmt"
 	"image"
-	"image/draw"
-	"runtime"
+	"sync"
 	"syscall"
 	"unsafe"
 
@@ -73,102 +74,238 @@ type RenderState struct {
 	statesInitialized bool
 }
 
-type Render struct {
-	animator      *Animator
-	window        windows.HWND
-	Routines      []func(*Render)
-	AX, AY        int
-	IsInside      bool
-	IsClicked     bool
-	d3d9Obj       *d3d9.Direct3D
-	device        *d3d9.Device
-	initialized   bool
-	renderState   *RenderState
-	quadVertices  []CUSTOM_VERTEX
-	OnDownMButton func(*Render)
-	OnUpMButton   func(*Render)
-	OnImage       func(*Render, image.Image) image.Image
-}
-
 type Rect struct {
 	Left, Top, Right, Bottom int32
 }
 
-func NewScreen() *Render {
-	return &Render{
-		renderState: &RenderState{},
+type POINT struct {
+	X, Y int32
+}
+
+type SIZE struct {
+	CX, CY int32
+}
+
+type BLENDFUNCTION struct {
+	BlendOp             byte
+	BlendFlags          byte
+	SourceConstantAlpha byte
+	AlphaFormat         byte
+}
+
+type WindowsBackend struct {
+	mu           sync.Mutex
+	window       windows.HWND
+	d3d9Obj      *d3d9.Direct3D
+	device       *d3d9.Device
+	target       *d3d9.Surface
+	readback     *d3d9.Surface
+	frame        *Compositor
+	initialized  bool
+	renderState  *RenderState
+	quadVertices []CUSTOM_VERTEX
+	handle       func(Event)
+	posted       []func()
+}
+
+type d3dTexture struct {
+	texture *d3d9.Texture
+	size    image.Point
+}
+
+func (t *d3dTexture) Size() image.Point {
+	return t.size
+}
+
+func (t *d3dTexture) Release() {
+	if t.texture != nil {
+		t.texture.Release()
+		t.texture = nil
 	}
 }
 
-func (s *Render) CurrentImage() image.Image {
-	if s.animator == nil {
-		return nil
+type gdiTexture struct {
+	image      image.Image
+	compositor *Compositor
+}
+
+func (t *gdiTexture) Size() image.Point {
+	return image.Pt(t.compositor.Width, t.compositor.Height)
+}
+
+func (t *gdiTexture) Release() {}
+
+func newDefaultBackend() Backend {
+	return NewWindowsBackend()
+}
+
+func NewWindowsBackend() *WindowsBackend {
+	return &WindowsBackend{
+		renderState: &RenderState{},
+		frame:       &Compositor{},
 	}
-	return s.animator.GetCurrentImage(s)
 }
 
-func (s *Render) ModuleHandle() windows.Handle {
+func (b *WindowsBackend) ModuleHandle() windows.Handle {
 	handle, _, _ := constants.ProcGetModuleHandle.Call(0)
 	return windows.Handle(handle)
 }
 
-func (s *Render) ScreenSize() (int, int) {
+func (b *WindowsBackend) ScreenSize() (int, int) {
 	width, _, _ := constants.ProcGetSystemMetrics.Call(0)
 	height, _, _ := constants.ProcGetSystemMetrics.Call(1)
 	return int(width), int(height)
 }
 
-func (s *Render) initD3D9() error {
-	var err error
+func (b *WindowsBackend) DPI() int {
+	if constants.ProcGetDpiForSystem.Find() != nil {
+		return DefaultDPI
+	}
+	dpi, _, _ := constants.ProcGetDpiForSystem.Call()
+	return int(dpi)
+}
+
+func (b *WindowsBackend) CursorPosition() (image.Point, error) {
+	var pt POINT
+	ret, _, err := constants.ProcGetCursorPos.Call(uintptr(unsafe.Pointer(&pt)))
+	if ret == 0 {
+		return image.Point{}, err
+	}
+	return image.Pt(int(pt.X), int(pt.Y)), nil
+}
 
-	s.d3d9Obj, err = d3d9.Create(d3d9.SDK_VERSION)
+func (b *WindowsBackend) CreateSurface(title string) error {
+	hInstance := b.ModuleHandle()
+	classNamePtr, err := windows.UTF16PtrFromString(title)
 	if err != nil {
 		return err
 	}
 
-	width, height := s.ScreenSize()
+	wndClass := WNDCLASS{
+		lpfnWndProc:   syscall.NewCallback(b.WindowProc),
+		hInstance:     hInstance,
+		lpszClassName: classNamePtr,
+	}
 
-	pp := d3d9.PRESENT_PARAMETERS{
-		Windowed:               1,
-		SwapEffect:             d3d9.SWAPEFFECT_DISCARD,
-		BackBufferFormat:       d3d9.FMT_UNKNOWN,
-		BackBufferWidth:        uint32(width),
-		BackBufferHeight:       uint32(height),
-		HDeviceWindow:          d3d9.HWND(s.window),
-		EnableAutoDepthStencil: 0,
-		Flags:                  d3d9.PRESENTFLAG_LOCKABLE_BACKBUFFER,
+	constants.ProcRegisterClass.Call(uintptr(unsafe.Pointer(&wndClass)))
+
+	width, height := b.ScreenSize()
+	ret, _, err := constants.ProcCreateWindowEx.Call(
+		uintptr(constants.WS_EX_TOPMOST|constants.WS_EX_LAYERED|constants.WS_EX_NOACTIVATE),
+		uintptr(unsafe.Pointer(classNamePtr)),
+		uintptr(unsafe.Pointer(windows.StringToUTF16Ptr("Overlay"))),
+		uintptr(constants.WS_POPUP|constants.WS_VISIBLE),
+		0, 0,
+		uintptr(width), uintptr(height),
+		0, 0, uintptr(hInstance), 0,
+	)
+	if ret == 0 {
+		return fmt.Errorf("failed to create window: %w", err)
 	}
 
-	s.device, _, err = s.d3d9Obj.CreateDevice(
+	b.mu.Lock()
+	b.window = windows.HWND(ret)
+	b.mu.Unlock()
+
+	if err := b.initD3D9(); err != nil {
+		b.initialized = false
+	}
+
+	constants.ProcSetWindowPos.Call(uintptr(b.window), ^uintptr(0), 0, 0, 0, 0, 0x0001|0x0002|0x0010)
+	constants.ProcShowWindow.Call(uintptr(b.window), constants.SW_SHOW)
+	constants.ProcUpdateWindow.Call(uintptr(b.window))
+
+	return nil
+}
+
+func (b *WindowsBackend) initD3D9() error {
+	var err error
+
+	b.d3d9Obj, err = d3d9.Create(d3d9.SDK_VERSION)
+	if err != nil {
+		return err
+	}
+
+	b.device, _, err = b.d3d9Obj.CreateDevice(
 		d3d9.ADAPTER_DEFAULT,
 		d3d9.DEVTYPE_HAL,
-		d3d9.HWND(s.window),
+		d3d9.HWND(b.window),
 		d3d9.CREATE_HARDWARE_VERTEXPROCESSING,
-		pp,
+		b.presentParameters(),
 	)
 	if err != nil {
-		s.device, _, err = s.d3d9Obj.CreateDevice(
+		b.device, _, err = b.d3d9Obj.CreateDevice(
 			d3d9.ADAPTER_DEFAULT,
 			d3d9.DEVTYPE_HAL,
-			d3d9.HWND(s.window),
+			d3d9.HWND(b.window),
 			d3d9.CREATE_SOFTWARE_VERTEXPROCESSING,
-			pp,
+			b.presentParameters(),
 		)
 		if err != nil {
 			return err
 		}
 	}
 
-	if err := s.createVertexBuffer(); err != nil {
+	if err := b.createVertexBuffer(); err != nil {
+		return err
+	}
+	if err := b.createTargets(); err != nil {
+		return err
+	}
+
+	b.initRenderStates()
+	b.initialized = true
+	return nil
+}
+
+func (b *WindowsBackend) presentParameters() d3d9.PRESENT_PARAMETERS {
+	width, height := b.ScreenSize()
+	return d3d9.PRESENT_PARAMETERS{
+		Windowed:               1,
+		SwapEffect:             d3d9.SWAPEFFECT_DISCARD,
+		BackBufferFormat:       d3d9.FMT_UNKNOWN,
+		BackBufferWidth:        uint32(width),
+		BackBufferHeight:       uint32(height),
+		HDeviceWindow:          d3d9.HWND(b.window),
+		EnableAutoDepthStencil: 0,
+		Flags:                  d3d9.PRESENTFLAG_LOCKABLE_BACKBUFFER,
+	}
+}
+
+func (b *WindowsBackend) createTargets() error {
+	width, height := b.ScreenSize()
+
+	target, err := b.device.CreateRenderTarget(uint(width), uint(height), d3d9.FMT_A8R8G8B8, d3d9.MULTISAMPLE_NONE, 0, false, 0)
+	if err != nil {
+		return err
+	}
+	readback, err := b.device.CreateOffscreenPlainSurface(uint(width), uint(height), d3d9.FMT_A8R8G8B8, d3d9.POOL_SYSTEMMEM, 0)
+	if err != nil {
+		target.Release()
+		return err
+	}
+	if err := b.device.SetRenderTarget(0, target); err != nil {
+		target.Release()
+		readback.Release()
 		return err
 	}
 
-	s.initRenderStates()
-	s.initialized = true
+	b.target, b.readback = target, readback
 	return nil
 }
 
-func (s *Render) createVertexBuffer() error {
+func (b *WindowsBackend) releaseTargets() {
+	if b.target != nil {
+		b.target.Release()
+		b.target = nil
+	}
+	if b.readback != nil {
+		b.readback.Release()
+		b.readback = nil
+	}
+}
+
+func (b *WindowsBackend) createVertexBuffer() error {
 	vertices := []CUSTOM_VERTEX{
 		{X: 0, Y: 0, Z: 0.0, Rhw: 1.0, Color: 0xFFFFFFFF, U: 0.0, V: 0.0},
 		{X: 1, Y: 0, Z: 0.0, Rhw: 1.0, Color: 0xFFFFFFFF, U: 1.0, V: 0.0},
@@ -176,50 +313,91 @@ func (s *Render) createVertexBuffer() error {
 		{X: 1, Y: 1, Z: 0.0, Rhw: 1.0, Color: 0xFFFFFFFF, U: 1.0, V: 1.0},
 	}
 
-	s.quadVertices = vertices
+	b.quadVertices = vertices
 	return nil
 }
 
-func (s *Render) initRenderStates() {
-	if s.renderState.statesInitialized {
+func (b *WindowsBackend) initRenderStates() {
+	if b.renderState.statesInitialized {
 		return
 	}
 
-	s.device.SetRenderState(d3d9.RS_CULLMODE, d3d9.CULL_NONE)
-	s.device.SetRenderState(d3d9.RS_LIGHTING, 0)
-	s.device.SetRenderState(d3d9.RS_ZENABLE, 0)
-	s.device.SetRenderState(d3d9.RS_ALPHABLENDENABLE, 1)
-	s.device.SetRenderState(d3d9.RS_SRCBLEND, d3d9.BLEND_SRCALPHA)
-	s.device.SetRenderState(d3d9.RS_DESTBLEND, d3d9.BLEND_INVSRCALPHA)
+	b.device.SetRenderState(d3d9.RS_CULLMODE, d3d9.CULL_NONE)
+	b.device.SetRenderState(d3d9.RS_LIGHTING, 0)
+	b.device.SetRenderState(d3d9.RS_ZENABLE, 0)
+	b.device.SetRenderState(d3d9.RS_ALPHABLENDENABLE, 1)
+	b.device.SetRenderState(d3d9.RS_SRCBLEND, d3d9.BLEND_ONE)
+	b.device.SetRenderState(d3d9.RS_DESTBLEND, d3d9.BLEND_INVSRCALPHA)
 
-	s.device.SetTextureStageState(0, d3d9.TSS_COLOROP, d3d9.TOP_SELECTARG1)
-	s.device.SetTextureStageState(0, d3d9.TSS_COLORARG1, d3d9.TA_TEXTURE)
-	s.device.SetTextureStageState(0, d3d9.TSS_ALPHAOP, d3d9.TOP_MODULATE)
-	s.device.SetTextureStageState(0, d3d9.TSS_ALPHAARG1, d3d9.TA_TEXTURE)
-	s.device.SetTextureStageState(0, d3d9.TSS_ALPHAARG2, d3d9.TA_DIFFUSE)
-	s.device.SetSamplerState(0, d3d9.SAMP_MINFILTER, d3d9.TEXF_LINEAR)
-	s.device.SetSamplerState(0, d3d9.SAMP_MAGFILTER, d3d9.TEXF_LINEAR)
-	s.device.SetFVF(CUSTOM_FVF)
+	b.device.SetTextureStageState(0, d3d9.TSS_COLOROP, d3d9.TOP_SELECTARG1)
+	b.device.SetTextureStageState(0, d3d9.TSS_COLORARG1, d3d9.TA_TEXTURE)
+	b.device.SetTextureStageState(0, d3d9.TSS_ALPHAOP, d3d9.TOP_MODULATE)
+	b.device.SetTextureStageState(0, d3d9.TSS_ALPHAARG1, d3d9.TA_TEXTURE)
+	b.device.SetTextureStageState(0, d3d9.TSS_ALPHAARG2, d3d9.TA_DIFFUSE)
+	b.device.SetSamplerState(0, d3d9.SAMP_MINFILTER, d3d9.TEXF_LINEAR)
+	b.device.SetSamplerState(0, d3d9.SAMP_MAGFILTER, d3d9.TEXF_LINEAR)
+	b.device.SetFVF(CUSTOM_FVF)
 
-	s.renderState.statesInitialized = true
+	b.renderState.statesInitialized = true
 }
 
-func (s *Render) setTextureIfChanged(texture *d3d9.Texture) {
-	if s.renderState.lastTexture != texture {
-		s.device.SetTexture(0, texture)
-		s.renderState.lastTexture = texture
+func (b *WindowsBackend) Upload(img image.Image) (Texture, error) {
+	bounds := img.Bounds()
+	compositor := NewCompositor(bounds.Dx(), bounds.Dy())
+	compositor.Draw(img, image.Point{})
+
+	if !b.initialized || b.device == nil {
+		return &gdiTexture{image: img, compositor: compositor}, nil
+	}
+
+	texture, err := b.device.CreateTexture(
+		uint(compositor.Width),
+		uint(compositor.Height),
+		1,
+		0,
+		d3d9.FMT_A8R8G8B8,
+		d3d9.POOL_MANAGED,
+		0,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	lockedRect, err := texture.LockRect(0, nil, 0)
+	if err != nil {
+		texture.Release()
+		return nil, err
+	}
+
+	pitch := int(lockedRect.Pitch)
+	bits := *(*unsafe.Pointer)(unsafe.Pointer(&lockedRect.PBits))
+	pixels := unsafe.Slice((*byte)(bits), pitch*compositor.Height)
+
+	for y := range compositor.Height {
+		copy(pixels[y*pitch:], compositor.Pix[y*compositor.Stride:(y+1)*compositor.Stride])
+	}
+
+	texture.UnlockRect(0)
+	return &d3dTexture{texture: texture, size: bounds.Size()}, nil
+}
+
+func (b *WindowsBackend) setTextureIfChanged(texture *d3d9.Texture) {
+	if b.renderState.lastTexture != texture {
+		b.device.SetTexture(0, texture)
+		b.renderState.lastTexture = texture
 	}
 }
 
-func (s *Render) RenderGPU(x, y int) {
-	if !s.initialized || s.animator == nil || s.device == nil {
+func (b *WindowsBackend) Present(layers []Layer) {
+	if !b.initialized || b.device == nil {
+		b.presentLayers(layers)
 		return
 	}
 
-	deviceStatusErr := s.device.TestCooperativeLevel()
+	deviceStatusErr := b.device.TestCooperativeLevel()
 	if deviceStatusErr != nil {
 		if deviceStatusErr.Code() == d3d9.ERR_DEVICENOTRESET {
-			s.resetDevice()
+			b.resetDevice()
 			return
 		} else {
 			fmt.Println("D3D9 device error:", deviceStatusErr)
@@ -227,161 +405,147 @@ func (s *Render) RenderGPU(x, y int) {
 		}
 	}
 
-	s.device.Clear(nil, d3d9.CLEAR_TARGET, d3d9.ColorRGBA(0, 0, 0, 0), 1.0, 0)
+	b.device.Clear(nil, d3d9.CLEAR_TARGET, d3d9.ColorRGBA(0, 0, 0, 0), 1.0, 0)
 
-	if err := s.device.BeginScene(); err != nil {
+	if err := b.device.BeginScene(); err != nil {
 		fmt.Println("Failed to begin scene:", err)
 		return
 	}
 
-	currentTexture := s.animator.GetCurrentTexture()
-	if currentTexture != nil {
-		bounds := s.animator.GetCurrentBounds()
-		s.renderTexturedQuadOptimized(x, y, bounds.Dx(), bounds.Dy(), currentTexture)
+	for _, layer := range layers {
+		if t, ok := layer.Texture.(*d3dTexture); ok && t != nil && t.texture != nil {
+			b.renderTexturedQuadOptimized(layer.At.X, layer.At.Y, t.size.X, t.size.Y, t.texture)
+		}
 	}
 
-	if err := s.device.EndScene(); err != nil {
+	if err := b.device.EndScene(); err != nil {
 		fmt.Println("Failed to end scene:", err)
 		return
 	}
 
-	s.device.Present(nil, nil, 0, nil)
+	b.presentTarget(layers)
 }
 
-func (s *Render) resetDevice() {
-	width, height := s.ScreenSize()
-	pp := d3d9.PRESENT_PARAMETERS{
-		Windowed:               1,
-		SwapEffect:             d3d9.SWAPEFFECT_DISCARD,
-		BackBufferFormat:       d3d9.FMT_UNKNOWN,
-		BackBufferWidth:        uint32(width),
-		BackBufferHeight:       uint32(height),
-		HDeviceWindow:          d3d9.HWND(s.window),
-		EnableAutoDepthStencil: 0,
-		Flags:                  d3d9.PRESENTFLAG_LOCKABLE_BACKBUFFER,
+func (b *WindowsBackend) presentTarget(layers []Layer) {
+	width, height := b.ScreenSize()
+	var bounds image.Rectangle
+	for _, layer := range layers {
+		if t, ok := layer.Texture.(*d3dTexture); ok && t != nil && t.texture != nil {
+			bounds = bounds.Union(image.Rectangle{Min: layer.At, Max: layer.At.Add(t.size)})
+		}
 	}
-
-	if _, err := s.device.Reset(pp); err != nil {
-		fmt.Println("Device reset failed:", err)
-		s.initialized = false
+	bounds = bounds.Intersect(image.Rect(0, 0, width, height))
+	if bounds.Empty() {
+		b.presentLayered(image.Point{}, NewCompositor(1, 1))
 		return
 	}
 
-	s.renderState.statesInitialized = false
-	s.initRenderStates()
-	fmt.Println("Device reset successfully.")
-}
-
-func (s *Render) renderTexturedQuadOptimized(x, y, width, height int, texture *d3d9.Texture) {
-	if texture == nil {
+	if err := b.device.GetRenderTargetData(b.target, b.readback); err != nil {
+		fmt.Println("Failed to read back frame:", err)
+		return
+	}
+	lockedRect, err := b.readback.LockRect(nil, d3d9.LOCK_READONLY)
+	if err != nil {
+		fmt.Println("Failed to lock frame:", err)
 		return
 	}
 
-	s.setTextureIfChanged(texture)
-
-	vertices := make([]CUSTOM_VERTEX, 4)
-	vertices[0] = CUSTOM_VERTEX{X: float32(x), Y: float32(y), Z: 0.0, Rhw: 1.0, Color: 0xFFFFFFFF, U: 0.0, V: 0.0}
-	vertices[1] = CUSTOM_VERTEX{X: float32(x + width), Y: float32(y), Z: 0.0, Rhw: 1.0, Color: 0xFFFFFFFF, U: 1.0, V: 0.0}
-	vertices[2] = CUSTOM_VERTEX{X: float32(x), Y: float32(y + height), Z: 0.0, Rhw: 1.0, Color: 0xFFFFFFFF, U: 0.0, V: 1.0}
-	vertices[3] = CUSTOM_VERTEX{X: float32(x + width), Y: float32(y + height), Z: 0.0, Rhw: 1.0, Color: 0xFFFFFFFF, U: 1.0, V: 1.0}
+	b.frame.Resize(bounds.Dx(), bounds.Dy())
+	pitch := int(lockedRect.Pitch)
+	bits := *(*unsafe.Pointer)(unsafe.Pointer(&lockedRect.PBits))
+	pixels := unsafe.Slice((*byte)(bits), pitch*height)
+	for y := range b.frame.Height {
+		offset := (bounds.Min.Y+y)*pitch + bounds.Min.X*4
+		copy(b.frame.Pix[y*b.frame.Stride:(y+1)*b.frame.Stride], pixels[offset:])
+	}
+	b.readback.UnlockRect()
 
-	s.device.DrawPrimitiveUP(
-		d3d9.PT_TRIANGLESTRIP,
-		2,
-		uintptr(unsafe.Pointer(&vertices[0])),
-		uint(unsafe.Sizeof(CUSTOM_VERTEX{})),
-	)
+	b.presentLayered(bounds.Min, b.frame)
 }
 
-func (s *Render) WindowProc(hwnd windows.HWND, msg uint32, wParam, lParam uintptr) uintptr {
-	switch msg {
-	case constants.WM_PAINT:
-		if s.initialized {
-			s.RenderGPU(s.AX, s.AY)
-		} else {
-			s.Render(s.AX, s.AY)
-		}
-		return 0
-	case constants.WM_KEYDOWN:
-		if wParam == constants.VK_ESCAPE {
-			constants.ProcPostQuitMessage.Call(0)
-		}
-		return 0
-	case constants.WM_DESTROY:
-		s.cleanup()
-		constants.ProcPostQuitMessage.Call(0)
-		return 0
-	case constants.WM_RBUTTONDOWN:
-		if s.IsClicked {
-			constants.ProcPostQuitMessage.Call(0)
+func (b *WindowsBackend) presentLayers(layers []Layer) {
+	var textures []*gdiTexture
+	var points []image.Point
+	var bounds image.Rectangle
+
+	for _, layer := range layers {
+		if t, ok := layer.Texture.(*gdiTexture); ok && t != nil {
+			textures = append(textures, t)
+			points = append(points, layer.At)
+			bounds = bounds.Union(image.Rectangle{Min: layer.At, Max: layer.At.Add(t.Size())})
 		}
-		return 0
-	case constants.WM_MBUTTONDOWN:
-		s.OnDownMButton(s)
-		s.IsClicked = true
-		return 0
-	case constants.WM_MBUTTONUP:
-		s.OnUpMButton(s)
-		s.IsClicked = false
-		return 0
 	}
-	ret, _, _ := constants.ProcDefWindowProc.Call(uintptr(hwnd), uintptr(msg), wParam, lParam)
-	return ret
-}
 
-func (s *Render) ClearWindow() {
-	if s.initialized && s.device != nil {
-		s.device.Clear(nil, d3d9.CLEAR_TARGET, d3d9.ColorRGBA(0, 0, 0, 0), 1.0, 0)
-		s.device.Present(nil, nil, 0, nil)
-	} else {
-		s.clearWindowGDI()
+	switch len(textures) {
+	case 0:
+		b.presentLayered(image.Point{}, NewCompositor(1, 1))
+	case 1:
+		b.presentLayered(points[0], textures[0].compositor)
+	default:
+		compositor := NewCompositor(bounds.Dx(), bounds.Dy())
+		for i, t := range textures {
+			compositor.Draw(t.image, points[i].Sub(bounds.Min))
+		}
+		b.presentLayered(bounds.Min, compositor)
 	}
 }
 
-func (s *Render) clearWindowGDI() {
-	if s.window == 0 {
-		return
-	}
+func (b *WindowsBackend) Clear() {
+	b.Present(nil)
+}
 
-	hdc, _, _ := constants.ProcGetWindowDC.Call(uintptr(s.window))
-	if hdc == 0 {
+func (b *WindowsBackend) resetDevice() {
+	b.releaseTargets()
+	if _, err := b.device.Reset(b.presentParameters()); err != nil {
+		fmt.Println("Device reset failed:", err)
+		b.initialized = false
 		return
 	}
-	defer constants.ProcReleaseDC.Call(uintptr(s.window), hdc)
-
-	width, height := s.ScreenSize()
-	brush, _, _ := constants.ProcCreateSolidBrush.Call(uintptr(constants.TRANSPARENT_COLOR))
-	if brush == 0 {
+	if err := b.createTargets(); err != nil {
+		fmt.Println("Device reset failed:", err)
+		b.initialized = false
 		return
 	}
-	defer constants.ProcDeleteObject.Call(brush)
 
-	rect := Rect{0, 0, int32(width), int32(height)}
-	constants.ProcFillRect.Call(hdc, uintptr(unsafe.Pointer(&rect)), brush)
-	constants.ProcInvalidateRect.Call(uintptr(s.window), 0, 1)
+	b.renderState.statesInitialized = false
+	b.renderState.lastTexture = nil
+	b.initRenderStates()
+	fmt.Println("Device reset successfully.")
 }
 
-func (s *Render) Render(x, y int) {
-	if s.animator == nil {
+func (b *WindowsBackend) renderTexturedQuadOptimized(x, y, width, height int, texture *d3d9.Texture) {
+	if texture == nil {
 		return
 	}
 
-	img := s.CurrentImage()
-	if img == nil {
+	b.setTextureIfChanged(texture)
+
+	vertices := make([]CUSTOM_VERTEX, 4)
+	vertices[0] = CUSTOM_VERTEX{X: float32(x), Y: float32(y), Z: 0.0, Rhw: 1.0, Color: 0xFFFFFFFF, U: 0.0, V: 0.0}
+	vertices[1] = CUSTOM_VERTEX{X: float32(x + width), Y: float32(y), Z: 0.0, Rhw: 1.0, Color: 0xFFFFFFFF, U: 1.0, V: 0.0}
+	vertices[2] = CUSTOM_VERTEX{X: float32(x), Y: float32(y + height), Z: 0.0, Rhw: 1.0, Color: 0xFFFFFFFF, U: 0.0, V: 1.0}
+	vertices[3] = CUSTOM_VERTEX{X: float32(x + width), Y: float32(y + height), Z: 0.0, Rhw: 1.0, Color: 0xFFFFFFFF, U: 1.0, V: 1.0}
+
+	b.device.DrawPrimitiveUP(
+		d3d9.PT_TRIANGLESTRIP,
+		2,
+		uintptr(unsafe.Pointer(&vertices[0])),
+		uint(unsafe.Sizeof(CUSTOM_VERTEX{})),
+	)
+}
+
+func (b *WindowsBackend) presentLayered(at image.Point, c *Compositor) {
+	if b.window == 0 || c.Width == 0 || c.Height == 0 {
 		return
 	}
 
-	bounds := img.Bounds()
-	rgba := image.NewRGBA(bounds)
-	draw.Draw(rgba, bounds, img, bounds.Min, draw.Over)
-
-	hdc, _, _ := constants.ProcGetWindowDC.Call(uintptr(s.window))
-	if hdc == 0 {
+	screenDC, _, _ := constants.ProcGetWindowDC.Call(0)
+	if screenDC == 0 {
 		return
 	}
-	defer constants.ProcReleaseDC.Call(uintptr(s.window), hdc)
+	defer constants.ProcReleaseDC.Call(0, screenDC)
 
-	memDC, _, _ := constants.ProcCreateCompatibleDC.Call(hdc)
+	memDC, _, _ := constants.ProcCreateCompatibleDC.Call(screenDC)
 	if memDC == 0 {
 		return
 	}
@@ -390,121 +554,161 @@ func (s *Render) Render(x, y int) {
 	bitmapInfo := BITMAPINFO{
 		bmiHeader: BITMAPINFOHEADER{
 			biSize:        uint32(unsafe.Sizeof(BITMAPINFOHEADER{})),
-			biWidth:       int32(bounds.Dx()),
-			biHeight:      -int32(bounds.Dy()),
+			biWidth:       int32(c.Width),
+			biHeight:      -int32(c.Height),
 			biPlanes:      1,
 			biBitCount:    32,
 			biCompression: 0,
 		},
 	}
 
-	var pixelPtr uintptr
-	hBitmap, _, _ := constants.ProcCreateDIBSection.Call(hdc, uintptr(unsafe.Pointer(&bitmapInfo)), 0, uintptr(unsafe.Pointer(&pixelPtr)), 0, 0)
-	if hBitmap == 0 || pixelPtr == 0 {
+	var bits unsafe.Pointer
+	hBitmap, _, _ := constants.ProcCreateDIBSection.Call(screenDC, uintptr(unsafe.Pointer(&bitmapInfo)), 0, uintptr(unsafe.Pointer(&bits)), 0, 0)
+	if hBitmap == 0 || bits == nil {
 		return
 	}
 	defer constants.ProcDeleteObject.Call(hBitmap)
 
-	pixels := make([]byte, len(rgba.Pix))
-	for i := 0; i < len(rgba.Pix); i += 4 {
-		a := rgba.Pix[i+3]
-		if a == 0 {
-			pixels[i], pixels[i+1], pixels[i+2], pixels[i+3] = 0, 0, 0, 0
-		} else {
-			pixels[i], pixels[i+1], pixels[i+2], pixels[i+3] =
-				rgba.Pix[i+2], rgba.Pix[i+1], rgba.Pix[i], rgba.Pix[i+3]
-		}
-	}
-
-	if s.IsClicked {
-		s.ClearWindow()
-	}
+	copy(unsafe.Slice((*byte)(bits), len(c.Pix)), c.Pix)
 
 	oldBitmap, _, _ := constants.ProcSelectObject.Call(memDC, hBitmap)
-	constants.ProcBitBlt.Call(hdc, uintptr(x), uintptr(y), uintptr(bounds.Dx()), uintptr(bounds.Dy()), memDC, 0, 0, constants.SRCCOPY)
-	constants.ProcSelectObject.Call(memDC, oldBitmap)
+	defer constants.ProcSelectObject.Call(memDC, oldBitmap)
+
+	position := POINT{X: int32(at.X), Y: int32(at.Y)}
+	size := SIZE{CX: int32(c.Width), CY: int32(c.Height)}
+	source := POINT{}
+	blend := BLENDFUNCTION{
+		BlendOp:             constants.AC_SRC_OVER,
+		SourceConstantAlpha: 255,
+		AlphaFormat:         constants.AC_SRC_ALPHA,
+	}
+
+	constants.ProcUpdateLayeredWindow.Call(
+		uintptr(b.window),
+		screenDC,
+		uintptr(unsafe.Pointer(&position)),
+		uintptr(unsafe.Pointer(&size)),
+		memDC,
+		uintptr(unsafe.Pointer(&source)),
+		0,
+		uintptr(unsafe.Pointer(&blend)),
+		constants.ULW_ALPHA,
+	)
 }
 
-func (s *Render) CreateWindow(className, imagePath string) error {
-	runtime.LockOSThread()
-	defer runtime.UnlockOSThread()
+func (b *WindowsBackend) WindowProc(hwnd windows.HWND, msg uint32, wParam, lParam uintptr) uintptr {
+	switch msg {
+	case constants.WM_PAINT:
+		b.dispatch(Event{Kind: EventPaint})
+		return 0
+	case constants.WM_KEYDOWN:
+		b.dispatch(Event{Kind: EventKeyDown, Key: Key(wParam)})
+		return 0
+	case constants.WM_DESTROY:
+		b.dispatch(Event{Kind: EventClose})
+		constants.ProcPostQuitMessage.Call(0)
+		return 0
+	case constants.WM_RBUTTONDOWN:
+		b.dispatch(Event{Kind: EventMouseDown, Button: ButtonRight})
+		return 0
+	case constants.WM_MBUTTONDOWN:
+		b.dispatch(Event{Kind: EventMouseDown, Button: ButtonMiddle})
+		return 0
+	case constants.WM_MBUTTONUP:
+		b.dispatch(Event{Kind: EventMouseUp, Button: ButtonMiddle})
+		return 0
+	case constants.WM_APP:
+		b.runPosted()
+		return 0
+	}
+	ret, _, _ := constants.ProcDefWindowProc.Call(uintptr(hwnd), uintptr(msg), wParam, lParam)
+	return ret
+}
 
-	var err error
-	s.animator, err = NewGPUAnimator(s.device, imagePath)
-	if err != nil {
-		return err
+func (b *WindowsBackend) dispatch(event Event) {
+	if b.handle != nil {
+		b.handle(event)
 	}
+}
 
-	hInstance := s.ModuleHandle()
-	classNamePtr, _ := windows.UTF16PtrFromString(className)
+func (b *WindowsBackend) Invalidate() {
+	b.mu.Lock()
+	window := b.window
+	b.mu.Unlock()
 
-	wndClass := WNDCLASS{
-		lpfnWndProc:   syscall.NewCallback(s.WindowProc),
-		hInstance:     hInstance,
-		lpszClassName: classNamePtr,
+	if window != 0 {
+		constants.ProcInvalidateRect.Call(uintptr(window), 0, 1)
 	}
+}
 
-	constants.ProcRegisterClass.Call(uintptr(unsafe.Pointer(&wndClass)))
+func (b *WindowsBackend) Post(fn func()) {
+	b.mu.Lock()
+	b.posted = append(b.posted, fn)
+	window := b.window
+	b.mu.Unlock()
 
-	width, height := s.ScreenSize()
-	ret, _, _ := constants.ProcCreateWindowEx.Call(
-		uintptr(constants.WS_EX_TOPMOST|constants.WS_EX_LAYERED|constants.WS_EX_NOACTIVATE),
-		uintptr(unsafe.Pointer(classNamePtr)),
-		uintptr(unsafe.Pointer(windows.StringToUTF16Ptr("Overlay"))),
-		uintptr(constants.WS_POPUP|constants.WS_VISIBLE),
-		0, 0,
-		uintptr(width), uintptr(height),
-		0, 0, uintptr(hInstance), 0,
-	)
+	if window != 0 {
+		constants.ProcPostMessage.Call(uintptr(window), constants.WM_APP, 0, 0)
+	}
+}
 
-	s.window = windows.HWND(ret)
+func (b *WindowsBackend) runPosted() {
+	b.mu.Lock()
+	posted := b.posted
+	b.posted = nil
+	b.mu.Unlock()
 
-	if err := s.initD3D9(); err != nil {
-		s.initialized = false
+	for _, fn := range posted {
+		b.dispatch(Event{Kind: EventCall, Call: fn})
 	}
+}
 
-	s.animator.SetDevice(s.device, s.OnImage, s)
-
-	constants.ProcSetWindowPos.Call(uintptr(s.window), ^uintptr(0), 0, 0, 0, 0, 0x0001|0x0002|0x0010)
-	constants.ProcSetLayeredWindowAttributes.Call(uintptr(s.window), constants.TRANSPARENT_COLOR, 255, constants.LWA_COLORKEY|constants.LWA_ALPHA)
-	constants.ProcShowWindow.Call(uintptr(s.window), constants.SW_SHOW)
-	constants.ProcUpdateWindow.Call(uintptr(s.window))
+func (b *WindowsBackend) Run(handle func(Event)) error {
+	b.handle = handle
+	defer func() {
+		b.handle = nil
+	}()
 
-	s.animator.Start()
-	s.RunRoutines()
+	b.runPosted()
 
 	var msg MSG
 	for {
-		ret, _, _ := constants.ProcGetMessage.Call(uintptr(unsafe.Pointer(&msg)), 0, 0, 0)
-		if ret == 0 || ret == ^uintptr(0) {
-			break
+		ret, _, err := constants.ProcGetMessage.Call(uintptr(unsafe.Pointer(&msg)), 0, 0, 0)
+		if ret == 0 {
+			return nil
+		}
+		if ret == ^uintptr(0) {
+			return err
 		}
 		constants.ProcTranslateMessage.Call(uintptr(unsafe.Pointer(&msg)))
 		constants.ProcDispatchMessage.Call(uintptr(unsafe.Pointer(&msg)))
 	}
+}
 
-	s.animator.Stop()
-	s.cleanup()
-	return nil
+func (b *WindowsBackend) Quit() {
+	constants.ProcPostQuitMessage.Call(0)
 }
 
-func (s *Render) RunRoutines() {
-	for _, routine := range s.Routines {
-		go routine(s)
+func (b *WindowsBackend) Close() {
+	b.mu.Lock()
+	window := b.window
+	b.mu.Unlock()
+
+	if window != 0 {
+		constants.ProcPostMessage.Call(uintptr(window), constants.WM_CLOSE, 0, 0)
 	}
 }
 
-func (s *Render) cleanup() {
-	if s.animator != nil {
-		s.animator.Cleanup()
-	}
-	if s.device != nil {
-		s.device.Release()
-		s.device = nil
+func (b *WindowsBackend) Release() {
+	b.releaseTargets()
+	if b.device != nil {
+		b.device.Release()
+		b.device = nil
 	}
-	if s.d3d9Obj != nil {
-		s.d3d9Obj.Release()
-		s.d3d9Obj = nil
+	if b.d3d9Obj != nil {
+		b.d3d9Obj.Release()
+		b.d3d9Obj = nil
 	}
-	s.initialized = false
+	b.renderState.lastTexture = nil
+	b.initialized = false
 }
@@ -0,0 +1,12 @@
+//go:build !windows
+
+package graphics
+
+const (
+	DefaultHeadlessWidth  = 1920
+	DefaultHeadlessHeight = 1080
+)
+
+func newDefaultBackend() Backend {
+	return NewMemoryBackend(DefaultHeadlessWidth, DefaultHeadlessHeight)
+}
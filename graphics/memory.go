@@ -0,0 +1,200 @@
+package graphics
+
+import (
+	"errors"
+	"image"
+	"image/draw"
+	"sync"
+)
+
+const DefaultDPI = 96
+
+type MemoryBackend struct {
+	mu       sync.Mutex
+	width    int
+	height   int
+	dpi      int
+	title    string
+	frame    *image.RGBA
+	cursor   image.Point
+	presents int
+	releases int
+	events   chan Event
+	quit     chan struct{}
+	quitOnce sync.Once
+}
+
+type memoryTexture struct {
+	image *image.RGBA
+}
+
+func (t *memoryTexture) Size() image.Point {
+	return t.image.Bounds().Size()
+}
+
+func (t *memoryTexture) Release() {}
+
+func NewMemoryBackend(width, height int) *MemoryBackend {
+	return &MemoryBackend{
+		width:  width,
+		height: height,
+		dpi:    DefaultDPI,
+		events: make(chan Event, 64),
+		quit:   make(chan struct{}),
+	}
+}
+
+func (b *MemoryBackend) CreateSurface(title string) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.width <= 0 || b.height <= 0 {
+		return errors.New("memory backend needs a positive surface size")
+	}
+
+	b.title = title
+	b.frame = image.NewRGBA(image.Rect(0, 0, b.width, b.height))
+	return nil
+}
+
+func (b *MemoryBackend) Title() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return b.title
+}
+
+func (b *MemoryBackend) ScreenSize() (int, int) {
+	return b.width, b.height
+}
+
+func (b *MemoryBackend) SetDPI(dpi int) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.dpi = dpi
+}
+
+func (b *MemoryBackend) DPI() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return b.dpi
+}
+
+func (b *MemoryBackend) SetCursor(x, y int) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.cursor = image.Pt(x, y)
+}
+
+func (b *MemoryBackend) CursorPosition() (image.Point, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return b.cursor, nil
+}
+
+func (b *MemoryBackend) Upload(img image.Image) (Texture, error) {
+	bounds := img.Bounds()
+	rgba := image.NewRGBA(image.Rect(0, 0, bounds.Dx(), bounds.Dy()))
+	draw.Draw(rgba, rgba.Bounds(), img, bounds.Min, draw.Src)
+
+	return &memoryTexture{image: rgba}, nil
+}
+
+func (b *MemoryBackend) Present(layers []Layer) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.frame == nil {
+		return
+	}
+
+	clear(b.frame.Pix)
+	for _, layer := range layers {
+		if t, ok := layer.Texture.(*memoryTexture); ok && t != nil {
+			rect := t.image.Bounds().Add(layer.At)
+			draw.Draw(b.frame, rect, t.image, image.Point{}, draw.Over)
+		}
+	}
+	b.presents++
+}
+
+func (b *MemoryBackend) Clear() {
+	b.Present(nil)
+}
+
+func (b *MemoryBackend) Frame() *image.RGBA {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.frame == nil {
+		return nil
+	}
+
+	frame := image.NewRGBA(b.frame.Bounds())
+	copy(frame.Pix, b.frame.Pix)
+	return frame
+}
+
+func (b *MemoryBackend) Presents() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return b.presents
+}
+
+func (b *MemoryBackend) Send(event Event) {
+	select {
+	case b.events <- event:
+	case <-b.quit:
+	}
+}
+
+func (b *MemoryBackend) Invalidate() {
+	select {
+	case b.events <- Event{Kind: EventPaint}:
+	default:
+	}
+}
+
+func (b *MemoryBackend) Post(fn func()) {
+	b.Send(Event{Kind: EventCall, Call: fn})
+}
+
+func (b *MemoryBackend) Run(handle func(Event)) error {
+	for {
+		select {
+		case <-b.quit:
+			return nil
+		case event := <-b.events:
+			handle(event)
+		}
+	}
+}
+
+func (b *MemoryBackend) Quit() {
+	b.quitOnce.Do(func() {
+		close(b.quit)
+	})
+}
+
+func (b *MemoryBackend) Close() {
+	b.Send(Event{Kind: EventClose})
+}
+
+func (b *MemoryBackend) Release() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.releases++
+}
+
+func (b *MemoryBackend) Releases() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return b.releases
+}
@@ -0,0 +1,63 @@
+package graphics
+
+import (
+	"time"
+
+	"github.com/fluffy-melli/visualio/images"
+)
+
+type Playback struct {
+	Mode        images.PlaybackMode
+	Loops       int
+	Start       int
+	End         int
+	Speed       float64
+	DelayPolicy images.DelayPolicy
+	MinDelay    time.Duration
+}
+
+func (p Playback) sequence(frameCount int) []int {
+	start := min(max(p.Start, 0), frameCount-1)
+	end := frameCount
+	if p.End > 0 {
+		end = min(p.End, frameCount)
+	}
+	if end <= start {
+		end = start + 1
+	}
+
+	sequence := make([]int, 0, 2*(end-start))
+
+	switch p.Mode {
+	case images.PlaybackReverse:
+		for i := end - 1; i >= start; i-- {
+			sequence = append(sequence, i)
+		}
+	case images.PlaybackPingPong:
+		for i := start; i < end; i++ {
+			sequence = append(sequence, i)
+		}
+		for i := end - 2; i > start; i-- {
+			sequence = append(sequence, i)
+		}
+	default:
+		for i := start; i < end; i++ {
+			sequence = append(sequence, i)
+		}
+	}
+
+	return sequence
+}
+
+func (p Playback) plays(sourcePlays int) int {
+	if p.Mode == images.PlaybackOnce {
+		return 1
+	}
+	switch {
+	case p.Loops < 0:
+		return 0
+	case p.Loops > 0:
+		return p.Loops
+	}
+	return sourcePlays
+}
@@ -2,460 +2,645 @@ package graphics
 
 import (
 	"bytes"
+	"errors"
 	"image"
-	"image/color"
-	"image/draw"
-	"image/gif"
 	"os"
+	"sync"
 	"time"
-	"unsafe"
 
-	"github.com/fluffy-melli/visualio/constants"
-	"github.com/gonutz/d3d9"
-	"golang.org/x/sys/windows"
+	"github.com/fluffy-melli/visualio/images"
 )
 
 type Animator struct {
-	hwnd              windows.HWND
-	device            *d3d9.Device
-	textures          []*d3d9.Texture
-	frames            []image.Image
-	delays            []int
-	currentFrame      int
-	done              chan bool
-	isAnimated        bool
-	staticImage       image.Image
-	staticTexture     *d3d9.Texture
-	isPreprocessed    bool
-	bounds            image.Rectangle
-	processedBounds   image.Rectangle
-	processFunc       func(*Render, image.Image) image.Image
-	render            *Render
-	needsUpdate       bool
-	processedTextures []*d3d9.Texture
-	processedStatic   *d3d9.Texture
-}
-
-func NewGPUAnimator(device *d3d9.Device, imagePath string) (*Animator, error) {
+	mu              sync.Mutex
+	backend         Backend
+	source          images.FrameSource
+	delays          []int
+	plays           int
+	playback        Playback
+	sequence        []int
+	timeline        *Timeline
+	clock           Clock
+	finished        bool
+	onFinish        func()
+	currentFrame    int
+	done            chan bool
+	wake            chan bool
+	running         bool
+	paused          bool
+	isAnimated      bool
+	staticImage     image.Image
+	bounds          image.Rectangle
+	processedBounds image.Rectangle
+	processFunc     func(*Render, image.Image) image.Image
+	render          *Render
+	processorKey    string
+	cache           *frameCache
+}
+
+func NewAnimator(imagePath string, memoryLimit int64) (*Animator, error) {
 	imageBytes, err := os.ReadFile(imagePath)
 	if err != nil {
 		return nil, err
 	}
 
 	if len(imageBytes) > 3 && string(imageBytes[:3]) == "GIF" {
-		return loadGPUGifAnimation(device, imageBytes)
+		return loadGifAnimation(imageBytes, memoryLimit)
+	}
+	if images.IsAPNG(imageBytes) {
+		return loadAPNGAnimation(imageBytes)
+	}
+	if images.IsAnimatedWebP(imageBytes) {
+		return loadWebPAnimation(imageBytes)
 	}
-	return loadGPUStaticImage(device, imageBytes)
+	return loadStaticImage(imageBytes)
 }
 
-func loadGPUGifAnimation(device *d3d9.Device, imageBytes []byte) (*Animator, error) {
-	gifImg, err := gif.DecodeAll(bytes.NewReader(imageBytes))
+func loadGifAnimation(imageBytes []byte, memoryLimit int64) (*Animator, error) {
+	source, err := images.LoadGIF(imageBytes, memoryLimit)
 	if err != nil {
 		return nil, err
 	}
+	return loadAnimation(source)
+}
 
-	animator := &Animator{
-		device:            device,
-		frames:            make([]image.Image, len(gifImg.Image)),
-		textures:          make([]*d3d9.Texture, len(gifImg.Image)),
-		processedTextures: make([]*d3d9.Texture, len(gifImg.Image)),
-		delays:            make([]int, len(gifImg.Image)),
-		currentFrame:      0,
-		done:              make(chan bool),
-		isAnimated:        true,
-		isPreprocessed:    false,
-		bounds:            gifImg.Image[0].Bounds(),
-		needsUpdate:       true,
-	}
-
-	bounds := gifImg.Image[0].Bounds()
-	accumulated := image.NewRGBA(bounds)
-
-	for i, frame := range gifImg.Image {
-		delay := max(gifImg.Delay[i]*10, 20)
-		animator.delays[i] = delay
-
-		disposal := gif.DisposalNone
-		if i < len(gifImg.Disposal) {
-			disposal = int(gifImg.Disposal[i])
-		}
+func loadAPNGAnimation(imageBytes []byte) (*Animator, error) {
+	animation, err := images.DecodeAPNG(imageBytes)
+	if err != nil {
+		return nil, err
+	}
+	return loadAnimation(animation)
+}
 
-		if i > 0 && disposal == gif.DisposalBackground {
-			draw.Draw(accumulated, bounds, &image.Uniform{color.RGBA{0, 0, 0, 0}}, image.Point{}, draw.Src)
-		}
+func loadWebPAnimation(imageBytes []byte) (*Animator, error) {
+	animation, err := images.DecodeWebP(imageBytes)
+	if err != nil {
+		return nil, err
+	}
+	return loadAnimation(animation)
+}
 
-		draw.Draw(accumulated, frame.Bounds(), frame, frame.Bounds().Min, draw.Over)
-		frameImg := image.NewRGBA(bounds)
-		draw.Draw(frameImg, bounds, accumulated, bounds.Min, draw.Src)
-		animator.frames[i] = frameImg
-	}
-
-	if device != nil {
-		for i, frame := range animator.frames {
-			texture, err := animator.createTextureFromImage(frame)
-			if err != nil {
-				for j := 0; j < i; j++ {
-					if animator.textures[j] != nil {
-						animator.textures[j].Release()
-					}
-				}
-				return nil, err
-			}
-			animator.textures[i] = texture
-		}
+func loadAnimation(source images.FrameSource) (*Animator, error) {
+	if source.FrameCount() == 0 {
+		return nil, errors.New("animation has no frames")
+	}
+
+	animator := &Animator{
+		source:       source,
+		cache:        newFrameCache(DefaultCacheBudget),
+		delays:       make([]int, source.FrameCount()),
+		plays:        source.PlayCount(),
+		currentFrame: 0,
+		isAnimated:   true,
+		bounds:       source.Canvas(),
+	}
+
+	for i := range animator.delays {
+		animator.delays[i] = source.FrameDelay(i)
 	}
 
 	return animator, nil
 }
 
-func loadGPUStaticImage(device *d3d9.Device, imageBytes []byte) (*Animator, error) {
+func loadStaticImage(imageBytes []byte) (*Animator, error) {
 	img, _, err := image.Decode(bytes.NewReader(imageBytes))
 	if err != nil {
 		return nil, err
 	}
 
 	animator := &Animator{
-		device:         device,
-		staticImage:    img,
-		isAnimated:     false,
-		done:           make(chan bool),
-		isPreprocessed: false,
-		bounds:         img.Bounds(),
-		needsUpdate:    true,
-	}
-
-	if device != nil {
-		texture, err := animator.createTextureFromImage(img)
-		if err != nil {
-			return nil, err
-		}
-		animator.staticTexture = texture
+		staticImage: img,
+		isAnimated:  false,
+		bounds:      img.Bounds(),
+		cache:       newFrameCache(DefaultCacheBudget),
 	}
 
 	return animator, nil
 }
 
 func (a *Animator) SetProcessor(processFunc func(*Render, image.Image) image.Image, render *Render) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
 	a.processFunc = processFunc
 	a.render = render
-	a.needsUpdate = true
+	a.cache.clear()
 }
 
-func (a *Animator) SetDevice(device *d3d9.Device, do func(*Render, image.Image) image.Image, r *Render) {
-	a.device = device
-	a.processFunc = do
-	a.render = r
-	a.needsUpdate = true
+func (a *Animator) SetProcessorKey(key string) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
 
-	a.cleanupTextures()
-	a.cleanupProcessedTextures()
-
-	if !a.isAnimated {
-		if a.staticImage != nil {
-			texture, err := a.createTextureFromImage(a.staticImage)
-			if err == nil {
-				a.staticTexture = texture
-			}
-		}
-	} else {
-		a.textures = make([]*d3d9.Texture, len(a.frames))
-		a.processedTextures = make([]*d3d9.Texture, len(a.frames))
-		for i, frame := range a.frames {
-			texture, err := a.createTextureFromImage(frame)
-			if err == nil {
-				a.textures[i] = texture
-			}
-		}
-	}
-}
-
-func (a *Animator) createTextureFromImage(img image.Image) (*d3d9.Texture, error) {
-	if a.device == nil {
-		return nil, nil
+	if key == a.processorKey {
+		return
 	}
 
-	bounds := img.Bounds()
-	width, height := bounds.Dx(), bounds.Dy()
-
-	rgba := image.NewRGBA(bounds)
-	draw.Draw(rgba, bounds, img, bounds.Min, draw.Src)
+	a.processorKey = key
+	a.processedBounds = image.Rectangle{}
+	a.cache.clear()
+}
 
-	texture, err := a.device.CreateTexture(
-		uint(width),
-		uint(height),
-		1,
-		0,
-		d3d9.FMT_A8R8G8B8,
-		d3d9.POOL_MANAGED,
-		0,
-	)
-	if err != nil {
-		return nil, err
-	}
+func (a *Animator) SetCacheBudget(budget int64) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
 
-	lockedRect, err := texture.LockRect(0, nil, 0)
-	if err != nil {
-		texture.Release()
-		return nil, err
-	}
+	a.cache.setBudget(budget)
+}
 
-	pitch := lockedRect.Pitch
-	pBits := lockedRect.PBits
+func (a *Animator) CacheUsage() (used, budget int64) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
 
-	for y := 0; y < height; y++ {
-		for x := 0; x < width; x++ {
-			srcOffset := (y*rgba.Stride + x*4)
-			dstOffset := y*int(pitch) + x*4
+	return a.cache.used, a.cache.budget
+}
 
-			if srcOffset+3 < len(rgba.Pix) && dstOffset+3 < int(lockedRect.Pitch)*height {
-				r := rgba.Pix[srcOffset+0]
-				g := rgba.Pix[srcOffset+1]
-				b := rgba.Pix[srcOffset+2]
-				a := rgba.Pix[srcOffset+3]
+func (a *Animator) SetBackend(backend Backend) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
 
-				size := width * height * 4
-				pixels := unsafe.Slice((*byte)(unsafe.Pointer(pBits)), size)
-				pixels[dstOffset+0] = b
-				pixels[dstOffset+1] = g
-				pixels[dstOffset+2] = r
-				pixels[dstOffset+3] = a
-			}
-		}
+	if backend == a.backend {
+		return
 	}
 
-	texture.UnlockRect(0)
-	return texture, nil
+	a.backend = backend
+	a.processedBounds = image.Rectangle{}
+	a.cache.clear()
 }
 
-func (a *Animator) Preprocess(s *Render) {
-	if a.isPreprocessed {
-		return
+func (a *Animator) GetCurrentImage(s *Render) image.Image {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	renderToUse := s
+	if renderToUse == nil {
+		renderToUse = a.render
 	}
-	a.cleanupTextures()
 
-	if !a.isAnimated {
-		if a.staticImage != nil {
-			if a.device != nil {
-				texture, err := a.createTextureFromImage(a.staticImage)
-				if err == nil {
-					a.staticTexture = texture
-				}
-			}
-		}
-	} else {
-		a.textures = make([]*d3d9.Texture, len(a.frames))
-		for i, frame := range a.frames {
-			a.frames[i] = frame
-
-			if a.device != nil {
-				texture, err := a.createTextureFromImage(frame)
-				if err == nil {
-					a.textures[i] = texture
-				}
-			}
-		}
+	if a.processFunc == nil || renderToUse == nil {
+		return a.currentImage()
 	}
 
-	a.isPreprocessed = true
+	entry := a.processedFrame(renderToUse)
+	if entry == nil {
+		return nil
+	}
+	return entry.image
 }
 
-func (a *Animator) GetCurrentImage(s *Render) image.Image {
-	if !a.isPreprocessed {
-		a.Preprocess(s)
+func (a *Animator) currentImage() image.Image {
+	if !a.isAnimated {
+		return a.staticImage
 	}
 
-	var currentImg image.Image
-	if !a.isAnimated {
-		currentImg = a.staticImage
-	} else {
-		if len(a.frames) == 0 {
-			return nil
-		}
-		currentImg = a.frames[a.currentFrame]
+	if a.currentFrame >= a.frameCount() {
+		return nil
 	}
 
-	if a.processFunc != nil {
-		renderToUse := s
-		if renderToUse == nil {
-			renderToUse = a.render
-		}
-		if renderToUse != nil {
-			return a.processFunc(renderToUse, currentImg)
-		}
+	frame, err := a.source.Frame(a.currentFrame)
+	if err != nil {
+		return nil
 	}
 
-	return currentImg
+	return frame
 }
 
-func (a *Animator) GetCurrentTexture() *d3d9.Texture {
-	if a.processFunc != nil && a.render != nil {
-		return a.getProcessedTexture()
+func (a *Animator) frameCount() int {
+	if a.source == nil {
+		return 0
 	}
+	return a.source.FrameCount()
+}
 
-	if !a.isAnimated {
-		return a.staticTexture
-	}
+func (a *Animator) GetCurrentTexture() Texture {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	return a.getProcessedTexture()
+}
+
+func (a *Animator) getProcessedTexture() Texture {
+	a.cache.releaseTextures()
 
-	if len(a.textures) == 0 || a.currentFrame >= len(a.textures) {
+	if a.backend == nil {
 		return nil
 	}
 
-	return a.textures[a.currentFrame]
-}
-
-func (a *Animator) getProcessedTexture() *d3d9.Texture {
-	if a.device == nil || a.processFunc == nil || a.render == nil {
+	entry := a.processedFrame(a.render)
+	if entry == nil {
 		return nil
 	}
 
-	var originalImg image.Image
-	if !a.isAnimated {
-		originalImg = a.staticImage
-	} else {
-		if len(a.frames) == 0 || a.currentFrame >= len(a.frames) {
+	if entry.texture == nil {
+		texture, err := a.backend.Upload(entry.image)
+		if err != nil {
 			return nil
 		}
-		originalImg = a.frames[a.currentFrame]
+		a.cache.attachTexture(entry, texture)
 	}
 
-	if originalImg == nil {
-		return nil
+	a.processedBounds = entry.image.Bounds()
+
+	return entry.texture
+}
+
+func (a *Animator) processedFrame(render *Render) *cachedFrame {
+	key := frameKey{
+		frame:     a.currentFrame,
+		processor: a.processorKey,
+	}
+	if a.processFunc != nil && render != nil {
+		key.width, key.height = render.ScreenSize()
+		key.dpi = render.DPI()
 	}
 
-	processedImg := a.processFunc(a.render, originalImg)
-	if processedImg == nil {
-		return nil
+	if entry := a.cache.get(key); entry != nil {
+		return entry
 	}
 
-	texture, err := a.createTextureFromImage(processedImg)
-	if err != nil {
+	originalImg := a.currentImage()
+	if originalImg == nil {
 		return nil
 	}
 
-	if !a.isAnimated {
-		if a.processedStatic != nil {
-			a.processedStatic.Release()
-		}
-		a.processedStatic = texture
-	} else {
-		if a.processedTextures[a.currentFrame] != nil {
-			a.processedTextures[a.currentFrame].Release()
+	processedImg := originalImg
+	if a.processFunc != nil && render != nil {
+		processedImg = a.processFunc(render, originalImg)
+		if processedImg == nil {
+			return nil
 		}
-		a.processedTextures[a.currentFrame] = texture
 	}
 
-	a.processedBounds = processedImg.Bounds()
-
-	return texture
+	return a.cache.put(key, processedImg)
 }
 
 func (a *Animator) GetCurrentBounds() image.Rectangle {
-	if a.processFunc != nil && !a.processedBounds.Empty() {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if !a.processedBounds.Empty() {
 		return a.processedBounds
 	}
 	return a.bounds
 }
 
 func (a *Animator) GetCurrentImageRaw() image.Image {
-	if !a.isAnimated {
-		return a.staticImage
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	return a.currentImage()
+}
+
+func (a *Animator) SetPlayback(playback Playback) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	a.playback = playback
+	a.finished = false
+	a.timeline = nil
+	a.ensureTimeline()
+	a.notify()
+}
+
+func (a *Animator) SetClock(clock Clock) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	a.clock = clock
+	a.timeline = nil
+	a.ensureTimeline()
+}
+
+func (a *Animator) ensureTimeline() {
+	if a.timeline != nil || !a.isAnimated || a.frameCount() == 0 {
+		return
 	}
 
-	if len(a.frames) == 0 {
-		return nil
+	a.sequence = a.playback.sequence(a.frameCount())
+
+	delays := make([]time.Duration, len(a.sequence))
+	for i, index := range a.sequence {
+		delay := time.Duration(a.delays[index]) * time.Millisecond
+		delays[i] = a.playback.DelayPolicy.Apply(delay, a.playback.MinDelay)
+	}
+
+	a.timeline = NewTimeline(a.clock, delays, a.playback.plays(a.plays))
+	a.timeline.SetSpeed(a.playback.Speed)
+	if !a.running {
+		a.timeline.Pause()
+	}
+
+	a.currentFrame = a.sequence[0]
+}
+
+func (a *Animator) Sequence() []int {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	a.ensureTimeline()
+	if a.timeline == nil {
+		return []int{0}
 	}
+	return append([]int(nil), a.sequence...)
+}
+
+func (a *Animator) FrameDelays() []time.Duration {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	a.ensureTimeline()
+	if a.timeline == nil {
+		return []time.Duration{0}
+	}
+
+	delays := make([]time.Duration, len(a.timeline.delays))
+	for i, delay := range a.timeline.delays {
+		delays[i] = time.Duration(float64(delay) / a.timeline.Speed())
+	}
+	return delays
+}
+
+func (a *Animator) PlayCount() int {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	return a.playback.plays(a.plays)
+}
+
+func (a *Animator) SetOnFinish(onFinish func()) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	a.onFinish = onFinish
+}
 
-	return a.frames[a.currentFrame]
+func (a *Animator) IsFinished() bool {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	return a.finished
 }
 
 func (a *Animator) NextFrame() {
-	if a.isAnimated && len(a.frames) > 1 {
-		a.currentFrame = (a.currentFrame + 1) % len(a.frames)
-		a.needsUpdate = true
+	if !a.isAnimated || a.frameCount() <= 1 {
+		return
 	}
+
+	a.mu.Lock()
+	a.ensureTimeline()
+	position, _, _ := a.timeline.Position()
+	a.timeline.SeekPosition((position + 1) % len(a.sequence))
+	step := a.update()
+	a.mu.Unlock()
+
+	a.dispatch(step)
 }
 
 func (a *Animator) Start() {
-	if !a.isAnimated || len(a.frames) <= 1 {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if !a.isAnimated || a.frameCount() <= 1 || a.running {
 		return
 	}
+	a.ensureTimeline()
+
+	a.done = make(chan bool)
+	a.wake = make(chan bool, 1)
+	a.running = true
 
-	go func() {
-		for {
+	if !a.paused {
+		a.timeline.Resume()
+	}
+
+	go a.run(a.done, a.wake)
+}
+
+func (a *Animator) run(done, wake chan bool) {
+	for {
+		a.mu.Lock()
+		step := a.update()
+		paused := a.paused
+		a.mu.Unlock()
+
+		a.dispatch(step)
+
+		if step.finished || paused {
 			select {
-			case <-a.done:
+			case <-done:
 				return
-			default:
-				delay := time.Duration(a.delays[a.currentFrame]) * time.Millisecond
-				time.Sleep(delay)
-				a.NextFrame()
-				if a.hwnd != 0 {
-					constants.ProcInvalidateRect.Call(uintptr(a.hwnd), 0, 1)
-				}
+			case <-wake:
+				continue
 			}
 		}
-	}()
+
+		timer := time.NewTimer(step.next)
+		select {
+		case <-done:
+			timer.Stop()
+			return
+		case <-wake:
+			timer.Stop()
+		case <-timer.C:
+		}
+	}
+}
+
+type frameStep struct {
+	changed   bool
+	completed bool
+	finished  bool
+	next      time.Duration
+}
+
+func (a *Animator) update() frameStep {
+	position, finished, next := a.timeline.Position()
+
+	step := frameStep{
+		finished: finished,
+		next:     next,
+	}
+
+	frame := a.sequence[position]
+	if frame != a.currentFrame {
+		a.currentFrame = frame
+		step.changed = true
+	}
+
+	step.completed = finished && !a.finished
+	a.finished = finished
+
+	return step
+}
+
+func (a *Animator) dispatch(step frameStep) {
+	a.mu.Lock()
+	backend := a.backend
+	onFinish := a.onFinish
+	a.mu.Unlock()
+
+	if step.changed && backend != nil {
+		backend.Invalidate()
+	}
+
+	if step.completed && onFinish != nil {
+		onFinish()
+	}
+}
+
+func (a *Animator) notify() {
+	if a.wake == nil {
+		return
+	}
+	select {
+	case a.wake <- true:
+	default:
+	}
 }
 
 func (a *Animator) Stop() {
-	if a.done != nil {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	a.stop()
+}
+
+func (a *Animator) stop() {
+	if a.running {
 		close(a.done)
+		a.running = false
+		if a.timeline != nil {
+			a.timeline.Pause()
+		}
 	}
 }
 
-func (a *Animator) cleanupTextures() {
-	if a.staticTexture != nil {
-		a.staticTexture.Release()
-		a.staticTexture = nil
+func (a *Animator) IsRunning() bool {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	return a.running
+}
+
+func (a *Animator) Pause() {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	a.paused = true
+	if a.timeline != nil {
+		a.timeline.Pause()
 	}
+	a.notify()
+}
 
-	for i, texture := range a.textures {
-		if texture != nil {
-			texture.Release()
-			a.textures[i] = nil
-		}
+func (a *Animator) Resume() {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	a.paused = false
+	if a.timeline != nil && a.running {
+		a.timeline.Resume()
 	}
+	a.notify()
+}
+
+func (a *Animator) IsPaused() bool {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	return a.paused
 }
 
-func (a *Animator) cleanupProcessedTextures() {
-	if a.processedStatic != nil {
-		a.processedStatic.Release()
-		a.processedStatic = nil
+func (a *Animator) SetSpeed(multiplier float64) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if multiplier <= 0 {
+		multiplier = 1
+	}
+	a.playback.Speed = multiplier
+	if a.timeline != nil {
+		a.timeline.SetSpeed(multiplier)
 	}
+	a.notify()
+}
 
-	for i, texture := range a.processedTextures {
-		if texture != nil {
-			texture.Release()
-			a.processedTextures[i] = nil
-		}
+func (a *Animator) Speed() float64 {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if a.timeline == nil {
+		return 1
 	}
+	return a.timeline.Speed()
 }
 
-func (a *Animator) Cleanup() {
-	a.Stop()
-	a.cleanupTextures()
-	a.cleanupProcessedTextures()
+func (a *Animator) Seek(frame int) {
+	if !a.isAnimated || a.frameCount() == 0 {
+		return
+	}
+
+	a.mu.Lock()
+	a.ensureTimeline()
+
+	frame = min(max(frame, 0), a.frameCount()-1)
+
+	position := 0
+	for i, index := range a.sequence {
+		if index == frame {
+			position = i
+			break
+		}
+	}
+
+	a.timeline.SeekPosition(position)
+	step := a.update()
+	a.notify()
+	a.mu.Unlock()
+
+	a.dispatch(step)
 }
 
-func (a *Animator) IsAnimated() bool {
-	return a.isAnimated
+func (a *Animator) SeekTime(offset time.Duration) {
+	if !a.isAnimated || a.frameCount() == 0 {
+		return
+	}
+
+	a.mu.Lock()
+	a.ensureTimeline()
+	a.timeline.Seek(offset)
+	step := a.update()
+	a.notify()
+	a.mu.Unlock()
+
+	a.dispatch(step)
 }
 
-func (a *Animator) IsPreprocessed() bool {
-	return a.isPreprocessed
+func (a *Animator) Cleanup() {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	a.stop()
+	a.cache.clear()
+	a.cache.releaseTextures()
 }
 
-func (a *Animator) ResetPreprocessing() {
-	a.isPreprocessed = false
-	a.needsUpdate = true
+func (a *Animator) IsAnimated() bool {
+	return a.isAnimated
 }
 
 func (a *Animator) HasProcessor() bool {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
 	return a.processFunc != nil && a.render != nil
 }
 
 func (a *Animator) RemoveProcessor() {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
 	a.processFunc = nil
 	a.render = nil
-	a.cleanupProcessedTextures()
+	a.processedBounds = image.Rectangle{}
+	a.cache.clear()
 }
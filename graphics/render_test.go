@@ -0,0 +1,255 @@
+package graphics
+
+import (
+	"image"
+	"image/color"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/fluffy-melli/visualio/images"
+)
+
+func testAnimation(frames, plays int) *images.Animation {
+	animation := &images.Animation{Plays: plays}
+	for i := range frames {
+		frame := image.NewRGBA(image.Rect(0, 0, 4, 4))
+		for p := 0; p < len(frame.Pix); p += 4 {
+			frame.Pix[p] = uint8(i * 40)
+			frame.Pix[p+3] = 255
+		}
+		animation.Frames = append(animation.Frames, frame)
+		animation.Delays = append(animation.Delays, 100)
+	}
+	return animation
+}
+
+func testAnimator(t *testing.T, frames, plays int, clock Clock) *Animator {
+	t.Helper()
+
+	animator, err := loadAnimation(testAnimation(frames, plays))
+	if err != nil {
+		t.Fatal(err)
+	}
+	animator.SetClock(clock)
+	animator.SetPlayback(Playback{DelayPolicy: images.DelayExact})
+	return animator
+}
+
+func waitFor(t *testing.T, what string, done func() bool) {
+	t.Helper()
+
+	deadline := time.Now().Add(2 * time.Second)
+	for !done() {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %s", what)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestAnimatorFollowsClock(t *testing.T) {
+	clock := newFakeClock()
+	animator := testAnimator(t, 3, 0, clock)
+	animator.SetBackend(NewMemoryBackend(8, 8))
+
+	animator.Start()
+	defer animator.Cleanup()
+
+	clock.Advance(ms(150))
+	animator.Resume()
+
+	waitFor(t, "frame 1", func() bool {
+		return animator.GetCurrentImageRaw().(*image.RGBA).Pix[0] == 40
+	})
+
+	animator.Pause()
+	clock.Advance(time.Second)
+	animator.SeekTime(ms(250))
+
+	if got := animator.GetCurrentImageRaw().(*image.RGBA).Pix[0]; got != 80 {
+		t.Fatalf("after SeekTime(250ms) red = %d, want frame 2", got)
+	}
+}
+
+func TestAnimatorFinishesOnce(t *testing.T) {
+	clock := newFakeClock()
+	animator := testAnimator(t, 2, 1, clock)
+	animator.SetBackend(NewMemoryBackend(8, 8))
+
+	var mu sync.Mutex
+	finished := 0
+	animator.SetOnFinish(func() {
+		mu.Lock()
+		finished++
+		mu.Unlock()
+	})
+
+	animator.Start()
+	defer animator.Cleanup()
+
+	clock.Advance(time.Second)
+	animator.Resume()
+
+	waitFor(t, "finish", animator.IsFinished)
+
+	animator.Resume()
+	animator.SetSpeed(2)
+	time.Sleep(10 * time.Millisecond)
+
+	mu.Lock()
+	defer mu.Unlock()
+	if finished != 1 {
+		t.Fatalf("OnFinish called %d times, want 1", finished)
+	}
+}
+
+func TestAnimatorConcurrentAccess(t *testing.T) {
+	clock := newFakeClock()
+	animator := testAnimator(t, 4, 0, clock)
+	backend := NewMemoryBackend(8, 8)
+	animator.SetBackend(backend)
+	animator.SetProcessor(func(r *Render, img image.Image) image.Image { return img }, NewScreen())
+
+	animator.Start()
+	defer animator.Cleanup()
+
+	var wg sync.WaitGroup
+	calls := []func(i int){
+		func(i int) { clock.Advance(ms(37)) },
+		func(i int) { animator.Pause(); animator.Resume() },
+		func(i int) { animator.Seek(i % 4) },
+		func(i int) { animator.SeekTime(ms(i * 13)) },
+		func(i int) { animator.SetSpeed(float64(i%3 + 1)) },
+		func(i int) { animator.NextFrame() },
+		func(i int) { animator.GetCurrentImage(nil) },
+		func(i int) { animator.GetCurrentTexture() },
+		func(i int) { animator.GetCurrentBounds() },
+		func(i int) { animator.SetCacheBudget(int64(i%2+1) * 64) },
+		func(i int) { animator.SetProcessorKey(string(rune('a' + i%3))) },
+		func(i int) { animator.IsFinished(); animator.IsPaused(); animator.Speed() },
+	}
+
+	for _, call := range calls {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range 200 {
+				call(i)
+			}
+		}()
+	}
+	wg.Wait()
+
+	animator.Stop()
+	if animator.IsRunning() {
+		t.Fatal("animator still running after Stop")
+	}
+}
+
+func TestRenderConcurrentState(t *testing.T) {
+	render := NewScreen()
+	render.SetBackend(NewMemoryBackend(8, 8))
+
+	var wg sync.WaitGroup
+	for range 8 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for range 500 {
+				render.Move(1, 1)
+				render.SetInside(true)
+				render.SetClicked(!render.IsClicked())
+				render.Snapshot()
+			}
+		}()
+	}
+	wg.Wait()
+
+	if x, y := render.Position(); x != 4000 || y != 4000 {
+		t.Fatalf("position = (%d, %d), want (4000, 4000)", x, y)
+	}
+}
+
+func TestRenderReloadWhileRunning(t *testing.T) {
+	backend := NewMemoryBackend(16, 16)
+	scene := NewScene()
+	scene.SetBackend(backend)
+
+	render := NewScreen()
+	scene.Add(render)
+	render.animator = testAnimator(t, 3, 0, newFakeClock())
+
+	done := make(chan error)
+	go func() {
+		done <- scene.CreateWindow("test")
+	}()
+
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		for range 50 {
+			render.Contains(image.Pt(1, 1))
+			render.Layer()
+		}
+	}()
+	go func() {
+		defer wg.Done()
+		for i := range 20 {
+			scene.Post(func() {
+				options := NewScreen()
+				options.ZIndex = i
+				options.Locked = i%2 == 0
+				render.SetOptions(options)
+				render.SetProcessor(func(r *Render, img image.Image) image.Image { return img }, string(rune('a'+i)))
+			})
+		}
+	}()
+	wg.Wait()
+	waitFor(t, "first paint", func() bool { return backend.Presents() > 0 })
+
+	backend.Send(Event{Kind: EventKeyDown, Key: KeyEscape})
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+
+	if render.order() != 19 || render.IsLocked() {
+		t.Fatalf("posted options not applied: z-index %d, locked %v", render.order(), render.IsLocked())
+	}
+	if backend.Frame().RGBAAt(0, 0) == (color.RGBA{}) {
+		t.Fatal("scene never presented the overlay")
+	}
+}
+
+func TestProcessorCacheFollowsScreen(t *testing.T) {
+	animator := testAnimator(t, 1, 0, newFakeClock())
+
+	backend := NewMemoryBackend(16, 16)
+	render := NewScreen()
+	render.SetBackend(backend)
+
+	var calls int
+	animator.SetProcessor(func(r *Render, img image.Image) image.Image {
+		calls++
+		return img
+	}, render)
+
+	animator.GetCurrentImage(nil)
+	animator.GetCurrentImage(nil)
+	if calls != 1 {
+		t.Fatalf("processor ran %d times for the same screen, want 1", calls)
+	}
+
+	backend.SetDPI(192)
+	animator.GetCurrentImage(nil)
+	if calls != 2 {
+		t.Fatalf("processor ran %d times after a DPI change, want 2", calls)
+	}
+
+	render.SetBackend(NewMemoryBackend(32, 16))
+	animator.GetCurrentImage(nil)
+	if calls != 3 {
+		t.Fatalf("processor ran %d times after a screen size change, want 3", calls)
+	}
+}
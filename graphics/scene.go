@@ -0,0 +1,199 @@
+package graphics
+
+import (
+	"fmt"
+	"image"
+	"runtime"
+	"sort"
+	"sync"
+)
+
+type Scene struct {
+	mu       sync.Mutex
+	backend  Backend
+	overlays []*Render
+}
+
+func NewScene() *Scene {
+	return &Scene{}
+}
+
+func (s *Scene) SetBackend(backend Backend) {
+	s.mu.Lock()
+	s.backend = backend
+	overlays := append([]*Render(nil), s.overlays...)
+	s.mu.Unlock()
+
+	for _, overlay := range overlays {
+		overlay.SetBackend(backend)
+	}
+}
+
+func (s *Scene) Backend() Backend {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.backend == nil {
+		s.backend = newDefaultBackend()
+	}
+	return s.backend
+}
+
+func (s *Scene) Add(overlay *Render) {
+	backend := s.Backend()
+
+	overlay.mu.Lock()
+	overlay.backend = backend
+	overlay.scene = s
+	overlay.mu.Unlock()
+
+	s.mu.Lock()
+	s.overlays = append(s.overlays, overlay)
+	s.mu.Unlock()
+}
+
+func (s *Scene) Overlays() []*Render {
+	s.mu.Lock()
+	overlays := append([]*Render(nil), s.overlays...)
+	s.mu.Unlock()
+
+	sort.SliceStable(overlays, func(i, j int) bool {
+		return overlays[i].order() < overlays[j].order()
+	})
+	return overlays
+}
+
+func (s *Scene) Post(fn func()) {
+	s.Backend().Post(fn)
+}
+
+func (s *Scene) OverlayAt(p image.Point) *Render {
+	overlays := s.Overlays()
+	for i := len(overlays) - 1; i >= 0; i-- {
+		if overlays[i].Contains(p) {
+			return overlays[i]
+		}
+	}
+	return nil
+}
+
+func (s *Scene) Paint() {
+	var layers []Layer
+	for _, overlay := range s.Overlays() {
+		if layer, ok := overlay.Layer(); ok {
+			layers = append(layers, layer)
+		}
+	}
+
+	if len(layers) == 0 {
+		s.Backend().Clear()
+		return
+	}
+	s.Backend().Present(layers)
+}
+
+func (s *Scene) CreateWindow(className string) error {
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
+	overlays := s.Overlays()
+	if len(overlays) == 0 {
+		return fmt.Errorf("scene has no overlays")
+	}
+
+	for _, overlay := range overlays {
+		if overlay.Animator() == nil {
+			return fmt.Errorf("overlay %q has no image loaded", overlay.Name)
+		}
+	}
+
+	backend := s.Backend()
+	if err := backend.CreateSurface(className); err != nil {
+		return err
+	}
+
+	for _, overlay := range overlays {
+		overlay.start(backend)
+	}
+	backend.Invalidate()
+
+	err := backend.Run(s.handle)
+
+	for _, overlay := range overlays {
+		overlay.stop()
+	}
+	s.cleanup()
+	return err
+}
+
+func (s *Scene) handle(event Event) {
+	switch event.Kind {
+	case EventPaint:
+		s.Paint()
+	case EventKeyDown:
+		if event.Key == KeyEscape {
+			s.Backend().Quit()
+		}
+	case EventCall:
+		event.Call()
+	case EventClose:
+		s.Backend().Quit()
+	case EventMouseDown:
+		switch event.Button {
+		case ButtonRight:
+			for _, overlay := range s.Overlays() {
+				if overlay.IsClicked() {
+					s.Backend().Quit()
+					return
+				}
+			}
+		case ButtonMiddle:
+			point, err := s.Backend().CursorPosition()
+			if err != nil {
+				return
+			}
+			overlay := s.OverlayAt(point)
+			if overlay == nil || overlay.IsLocked() {
+				return
+			}
+			if overlay.OnDownMButton != nil {
+				overlay.OnDownMButton(overlay)
+			}
+			overlay.SetClicked(true)
+		}
+	case EventMouseUp:
+		if event.Button != ButtonMiddle {
+			return
+		}
+		for _, overlay := range s.Overlays() {
+			if !overlay.IsClicked() {
+				continue
+			}
+			if overlay.OnUpMButton != nil {
+				overlay.OnUpMButton(overlay)
+			}
+			overlay.SetClicked(false)
+		}
+	}
+}
+
+func (s *Scene) hide(overlay *Render) {
+	overlay.mu.Lock()
+	overlay.hidden = true
+	overlay.mu.Unlock()
+
+	for _, other := range s.Overlays() {
+		if !other.IsHidden() {
+			s.Backend().Invalidate()
+			return
+		}
+	}
+	s.Backend().Close()
+}
+
+func (s *Scene) cleanup() {
+	for _, overlay := range s.Overlays() {
+		overlay.cleanup()
+	}
+	s.Backend().Release()
+}
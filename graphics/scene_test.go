@@ -0,0 +1,126 @@
+package graphics
+
+import (
+	"image"
+	"image/color"
+	"testing"
+
+	"github.com/fluffy-melli/visualio/images"
+)
+
+func solidAnimator(t *testing.T, c color.RGBA) *Animator {
+	t.Helper()
+
+	frame := image.NewRGBA(image.Rect(0, 0, 4, 4))
+	for p := 0; p < len(frame.Pix); p += 4 {
+		frame.Pix[p], frame.Pix[p+1], frame.Pix[p+2], frame.Pix[p+3] = c.R, c.G, c.B, c.A
+	}
+
+	animator, err := loadAnimation(&images.Animation{Frames: []*image.RGBA{frame}, Delays: []int{100}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	animator.SetClock(newFakeClock())
+	return animator
+}
+
+func TestSceneDragAndPaint(t *testing.T) {
+	red := color.RGBA{R: 255, A: 255}
+	blue := color.RGBA{B: 255, A: 255}
+
+	backend := NewMemoryBackend(16, 16)
+	scene := NewScene()
+	scene.SetBackend(backend)
+
+	bottom, top := NewScreen(), NewScreen()
+	bottom.animator = solidAnimator(t, red)
+	top.animator = solidAnimator(t, blue)
+	top.ZIndex, top.Locked = 1, true
+	top.SetPosition(8, 8)
+
+	var grabbed []*Render
+	bottom.OnDownMButton = func(r *Render) { grabbed = append(grabbed, r) }
+	top.OnDownMButton = func(r *Render) { grabbed = append(grabbed, r) }
+
+	scene.Add(top)
+	scene.Add(bottom)
+
+	done := make(chan error)
+	go func() {
+		done <- scene.CreateWindow("test")
+	}()
+
+	handled := func() {
+		t.Helper()
+		called := make(chan struct{})
+		scene.Post(func() { close(called) })
+		<-called
+	}
+	pixel := func(x, y int, want color.RGBA) {
+		t.Helper()
+		if got := backend.Frame().RGBAAt(x, y); got != want {
+			t.Fatalf("pixel (%d, %d) = %v, want %v", x, y, got, want)
+		}
+	}
+
+	waitFor(t, "first paint", func() bool { return backend.Presents() > 0 })
+	pixel(1, 1, red)
+	pixel(9, 9, blue)
+	pixel(6, 6, color.RGBA{})
+
+	backend.SetCursor(9, 9)
+	backend.Send(Event{Kind: EventMouseDown, Button: ButtonMiddle})
+	handled()
+	if len(grabbed) != 0 || top.IsClicked() {
+		t.Fatal("middle click grabbed a locked overlay")
+	}
+
+	backend.SetCursor(1, 1)
+	backend.Send(Event{Kind: EventMouseDown, Button: ButtonMiddle})
+	handled()
+	if len(grabbed) != 1 || grabbed[0] != bottom || !bottom.IsClicked() {
+		t.Fatal("middle click did not grab the overlay under the cursor")
+	}
+
+	bottom.Move(6, 6)
+	bottom.Invalidate()
+	backend.Send(Event{Kind: EventMouseUp, Button: ButtonMiddle})
+	handled()
+	if bottom.IsClicked() {
+		t.Fatal("middle button release left the overlay grabbed")
+	}
+
+	pixel(1, 1, color.RGBA{})
+	pixel(7, 7, red)
+	pixel(9, 9, blue)
+
+	backend.Send(Event{Kind: EventKeyDown, Key: KeyEscape})
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestSceneCloseReleasesOnce(t *testing.T) {
+	backend := NewMemoryBackend(16, 16)
+	scene := NewScene()
+	scene.SetBackend(backend)
+
+	overlay := NewScreen()
+	overlay.animator = solidAnimator(t, color.RGBA{R: 255, A: 255})
+	scene.Add(overlay)
+
+	done := make(chan error)
+	go func() {
+		done <- scene.CreateWindow("test")
+	}()
+
+	waitFor(t, "first paint", func() bool { return backend.Presents() > 0 })
+	overlay.Close()
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+
+	if got := backend.Releases(); got != 1 {
+		t.Fatalf("backend released %d times, want 1", got)
+	}
+}
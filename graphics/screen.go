@@ -0,0 +1,356 @@
+package graphics
+
+import (
+	"image"
+	"sync"
+)
+
+type Snapshot struct {
+	X, Y    int
+	Inside  bool
+	Clicked bool
+}
+
+type Render struct {
+	mu            sync.Mutex
+	state         Snapshot
+	animator      *Animator
+	backend       Backend
+	scene         *Scene
+	hidden        bool
+	running       bool
+	Name          string
+	ZIndex        int
+	Locked        bool
+	Routines      []func(*Render)
+	OnDownMButton func(*Render)
+	OnUpMButton   func(*Render)
+	OnFinish      func(*Render)
+	Playback      Playback
+	OnImage       func(*Render, image.Image) image.Image
+	ProcessorKey  string
+	CacheBudget   int64
+	MemoryLimit   int64
+}
+
+func NewScreen() *Render {
+	return &Render{}
+}
+
+func (s *Render) SetBackend(backend Backend) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.backend = backend
+}
+
+func (s *Render) Backend() Backend {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.backend == nil {
+		s.backend = newDefaultBackend()
+	}
+	return s.backend
+}
+
+func (s *Render) Snapshot() Snapshot {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.state
+}
+
+func (s *Render) Position() (int, int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.state.X, s.state.Y
+}
+
+func (s *Render) SetPosition(x, y int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.state.X, s.state.Y = x, y
+}
+
+func (s *Render) Move(dx, dy int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.state.X += dx
+	s.state.Y += dy
+}
+
+func (s *Render) IsInside() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.state.Inside
+}
+
+func (s *Render) SetInside(inside bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.state.Inside = inside
+}
+
+func (s *Render) IsClicked() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.state.Clicked
+}
+
+func (s *Render) SetClicked(clicked bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.state.Clicked = clicked
+}
+
+func (s *Render) Animator() *Animator {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.animator
+}
+
+func (s *Render) CurrentImage() image.Image {
+	animator := s.Animator()
+	if animator == nil {
+		return nil
+	}
+	return animator.GetCurrentImage(s)
+}
+
+func (s *Render) ScreenSize() (int, int) {
+	return s.Backend().ScreenSize()
+}
+
+func (s *Render) DPI() int {
+	return s.Backend().DPI()
+}
+
+func (s *Render) CursorPosition() (image.Point, error) {
+	return s.Backend().CursorPosition()
+}
+
+func (s *Render) Contains(p image.Point) bool {
+	if s.IsHidden() {
+		return false
+	}
+
+	img := s.CurrentImage()
+	if img == nil {
+		return false
+	}
+
+	x, y := s.Position()
+	return p.In(img.Bounds().Add(image.Pt(x, y)))
+}
+
+func (s *Render) Layer() (Layer, bool) {
+	animator := s.Animator()
+	if animator == nil || s.IsHidden() {
+		return Layer{}, false
+	}
+
+	texture := animator.GetCurrentTexture()
+	if texture == nil {
+		return Layer{}, false
+	}
+
+	bounds := animator.GetCurrentBounds()
+	x, y := s.Position()
+	return Layer{Texture: texture, At: image.Pt(x+bounds.Min.X, y+bounds.Min.Y)}, true
+}
+
+func (s *Render) IsHidden() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.hidden
+}
+
+func (s *Render) ClearWindow() {
+	s.Backend().Clear()
+}
+
+func (s *Render) IsLocked() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.Locked
+}
+
+func (s *Render) order() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.ZIndex
+}
+
+func (s *Render) SetOptions(options *Render) {
+	options.mu.Lock()
+	name, zIndex, locked := options.Name, options.ZIndex, options.Locked
+	onFinish, memoryLimit := options.OnFinish, options.MemoryLimit
+	options.mu.Unlock()
+
+	s.mu.Lock()
+	s.Name, s.ZIndex, s.Locked = name, zIndex, locked
+	s.OnFinish, s.MemoryLimit = onFinish, memoryLimit
+	s.mu.Unlock()
+}
+
+func (s *Render) Load(imagePath string) error {
+	s.mu.Lock()
+	memoryLimit := s.MemoryLimit
+	s.mu.Unlock()
+
+	animator, err := NewAnimator(imagePath, memoryLimit)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	process, key := s.OnImage, s.ProcessorKey
+	budget, playback := s.CacheBudget, s.Playback
+	s.mu.Unlock()
+
+	animator.SetProcessor(process, s)
+	animator.SetProcessorKey(key)
+	animator.SetCacheBudget(budget)
+	animator.SetPlayback(playback)
+	animator.SetOnFinish(func() {
+		s.mu.Lock()
+		onFinish := s.OnFinish
+		s.mu.Unlock()
+
+		if onFinish != nil {
+			onFinish(s)
+		}
+	})
+
+	s.mu.Lock()
+	previous := s.animator
+	s.animator = animator
+	running, backend := s.running, s.backend
+	s.mu.Unlock()
+
+	if previous != nil {
+		previous.Cleanup()
+	}
+
+	if running {
+		animator.SetBackend(backend)
+		animator.Start()
+		backend.Invalidate()
+	}
+	return nil
+}
+
+func (s *Render) SetProcessor(process func(*Render, image.Image) image.Image, key string) {
+	s.mu.Lock()
+	s.OnImage = process
+	s.ProcessorKey = key
+	animator := s.animator
+	s.mu.Unlock()
+
+	if animator != nil {
+		animator.SetProcessor(process, s)
+		animator.SetProcessorKey(key)
+	}
+	s.Invalidate()
+}
+
+func (s *Render) SetPlayback(playback Playback) {
+	s.mu.Lock()
+	s.Playback = playback
+	animator := s.animator
+	s.mu.Unlock()
+
+	if animator != nil {
+		animator.SetPlayback(playback)
+	}
+	s.Invalidate()
+}
+
+func (s *Render) SetCacheBudget(budget int64) {
+	s.mu.Lock()
+	s.CacheBudget = budget
+	animator := s.animator
+	s.mu.Unlock()
+
+	if animator != nil {
+		animator.SetCacheBudget(budget)
+	}
+}
+
+func (s *Render) Invalidate() {
+	s.mu.Lock()
+	running, backend := s.running, s.backend
+	s.mu.Unlock()
+
+	if running {
+		backend.Invalidate()
+	}
+}
+
+func (s *Render) start(backend Backend) {
+	s.mu.Lock()
+	s.backend = backend
+	s.running = true
+	animator := s.animator
+	s.mu.Unlock()
+
+	animator.SetBackend(backend)
+	animator.Start()
+	s.RunRoutines()
+}
+
+func (s *Render) stop() {
+	s.mu.Lock()
+	s.running = false
+	animator := s.animator
+	s.mu.Unlock()
+
+	animator.Stop()
+}
+
+func (s *Render) CreateWindow(className, imagePath string) error {
+	if err := s.Load(imagePath); err != nil {
+		return err
+	}
+
+	scene := NewScene()
+	scene.SetBackend(s.Backend())
+	scene.Add(s)
+	return scene.CreateWindow(className)
+}
+
+func (s *Render) Close() {
+	s.mu.Lock()
+	scene := s.scene
+	s.mu.Unlock()
+
+	if scene == nil {
+		s.Backend().Close()
+		return
+	}
+	scene.hide(s)
+}
+
+func (s *Render) RunRoutines() {
+	for _, routine := range s.Routines {
+		go routine(s)
+	}
+}
+
+func (s *Render) cleanup() {
+	if animator := s.Animator(); animator != nil {
+		animator.Cleanup()
+	}
+}
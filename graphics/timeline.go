@@ -0,0 +1,144 @@
+package graphics
+
+import (
+	"sort"
+	"time"
+)
+
+type Clock interface {
+	Now() time.Time
+}
+
+type systemClock struct{}
+
+func (systemClock) Now() time.Time {
+	return time.Now()
+}
+
+var SystemClock Clock = systemClock{}
+
+type Timeline struct {
+	clock   Clock
+	delays  []time.Duration
+	offsets []time.Duration
+	cycle   time.Duration
+	plays   int
+	speed   float64
+	base    time.Duration
+	anchor  time.Time
+	paused  bool
+}
+
+func NewTimeline(clock Clock, delays []time.Duration, plays int) *Timeline {
+	if clock == nil {
+		clock = SystemClock
+	}
+
+	t := &Timeline{
+		clock:   clock,
+		delays:  delays,
+		offsets: make([]time.Duration, len(delays)),
+		plays:   plays,
+		speed:   1,
+		anchor:  clock.Now(),
+	}
+
+	for i, delay := range delays {
+		t.offsets[i] = t.cycle
+		t.cycle += delay
+	}
+
+	return t
+}
+
+func (t *Timeline) Elapsed() time.Duration {
+	if t.paused {
+		return t.base
+	}
+	return t.base + time.Duration(float64(t.clock.Now().Sub(t.anchor))*t.speed)
+}
+
+func (t *Timeline) Cycle() time.Duration {
+	return t.cycle
+}
+
+func (t *Timeline) Position() (position int, finished bool, next time.Duration) {
+	if len(t.delays) == 0 {
+		return 0, true, 0
+	}
+
+	last := len(t.delays) - 1
+	if t.cycle <= 0 {
+		return last, true, 0
+	}
+
+	elapsed := t.Elapsed()
+	play := int(elapsed / t.cycle)
+	if t.plays > 0 && play >= t.plays {
+		return last, true, 0
+	}
+
+	within := elapsed % t.cycle
+	position = sort.Search(len(t.offsets), func(i int) bool {
+		return t.offsets[i] > within
+	}) - 1
+
+	remaining := t.offsets[position] + t.delays[position] - within
+	return position, false, time.Duration(float64(remaining) / t.speed)
+}
+
+func (t *Timeline) Pause() {
+	if t.paused {
+		return
+	}
+	t.base = t.Elapsed()
+	t.paused = true
+}
+
+func (t *Timeline) Resume() {
+	if !t.paused {
+		return
+	}
+	t.anchor = t.clock.Now()
+	t.paused = false
+}
+
+func (t *Timeline) IsPaused() bool {
+	return t.paused
+}
+
+func (t *Timeline) SetSpeed(speed float64) {
+	if speed <= 0 {
+		speed = 1
+	}
+	t.base = t.Elapsed()
+	t.anchor = t.clock.Now()
+	t.speed = speed
+}
+
+func (t *Timeline) Speed() float64 {
+	return t.speed
+}
+
+func (t *Timeline) Seek(elapsed time.Duration) {
+	t.base = max(elapsed, 0)
+	t.anchor = t.clock.Now()
+}
+
+func (t *Timeline) SeekPosition(position int) {
+	if len(t.offsets) == 0 {
+		return
+	}
+
+	position = min(max(position, 0), len(t.offsets)-1)
+
+	play := time.Duration(0)
+	if t.cycle > 0 {
+		play = t.Elapsed() / t.cycle
+		if t.plays > 0 {
+			play = min(play, time.Duration(t.plays-1))
+		}
+	}
+
+	t.Seek(play*t.cycle + t.offsets[position])
+}
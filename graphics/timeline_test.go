@@ -0,0 +1,158 @@
+package graphics
+
+import (
+	"sync"
+	"testing"
+	"time"
+)
+
+type fakeClock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+func newFakeClock() *fakeClock {
+	return &fakeClock{now: time.Unix(0, 0)}
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.now
+}
+
+func (c *fakeClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.now = c.now.Add(d)
+}
+
+func ms(n int) time.Duration {
+	return time.Duration(n) * time.Millisecond
+}
+
+func TestTimelinePositionAtBoundaries(t *testing.T) {
+	clock := newFakeClock()
+	timeline := NewTimeline(clock, []time.Duration{ms(100), ms(50), ms(200)}, 0)
+
+	tests := []struct {
+		at       time.Duration
+		position int
+		next     time.Duration
+	}{
+		{at: 0, position: 0, next: ms(100)},
+		{at: ms(99), position: 0, next: ms(1)},
+		{at: ms(100), position: 1, next: ms(50)},
+		{at: ms(149), position: 1, next: ms(1)},
+		{at: ms(150), position: 2, next: ms(200)},
+		{at: ms(349), position: 2, next: ms(1)},
+		{at: ms(350), position: 0, next: ms(100)},
+		{at: ms(700), position: 0, next: ms(100)},
+		{at: ms(800), position: 1, next: ms(50)},
+	}
+
+	elapsed := time.Duration(0)
+	for _, tt := range tests {
+		clock.Advance(tt.at - elapsed)
+		elapsed = tt.at
+
+		position, finished, next := timeline.Position()
+		if position != tt.position || finished || next != tt.next {
+			t.Errorf("at %v: got (%d, %v, %v), want (%d, false, %v)", tt.at, position, finished, next, tt.position, tt.next)
+		}
+	}
+}
+
+func TestTimelinePlaysExhausted(t *testing.T) {
+	clock := newFakeClock()
+	timeline := NewTimeline(clock, []time.Duration{ms(100), ms(100)}, 2)
+
+	clock.Advance(ms(399))
+	if position, finished, _ := timeline.Position(); position != 1 || finished {
+		t.Fatalf("before the last play ends: got (%d, %v), want (1, false)", position, finished)
+	}
+
+	clock.Advance(ms(1))
+	if position, finished, next := timeline.Position(); position != 1 || !finished || next != 0 {
+		t.Fatalf("after two plays: got (%d, %v, %v), want (1, true, 0)", position, finished, next)
+	}
+
+	clock.Advance(time.Hour)
+	if position, finished, _ := timeline.Position(); position != 1 || !finished {
+		t.Fatalf("long after finishing: got (%d, %v), want (1, true)", position, finished)
+	}
+}
+
+func TestTimelinePauseResume(t *testing.T) {
+	clock := newFakeClock()
+	timeline := NewTimeline(clock, []time.Duration{ms(100), ms(100)}, 0)
+
+	clock.Advance(ms(50))
+	timeline.Pause()
+	clock.Advance(time.Second)
+
+	if elapsed := timeline.Elapsed(); elapsed != ms(50) {
+		t.Fatalf("elapsed while paused = %v, want 50ms", elapsed)
+	}
+
+	timeline.Resume()
+	clock.Advance(ms(60))
+
+	if position, _, next := timeline.Position(); position != 1 || next != ms(90) {
+		t.Fatalf("after resume: got (%d, %v), want (1, 90ms)", position, next)
+	}
+}
+
+func TestTimelineSeek(t *testing.T) {
+	clock := newFakeClock()
+	timeline := NewTimeline(clock, []time.Duration{ms(100), ms(100), ms(100)}, 0)
+
+	timeline.Seek(ms(250))
+	if position, _, next := timeline.Position(); position != 2 || next != ms(50) {
+		t.Fatalf("Seek(250ms): got (%d, %v), want (2, 50ms)", position, next)
+	}
+
+	clock.Advance(ms(400))
+	timeline.SeekPosition(1)
+	if elapsed := timeline.Elapsed(); elapsed != ms(700) {
+		t.Fatalf("SeekPosition(1) in the third play: elapsed = %v, want 700ms", elapsed)
+	}
+
+	timeline.SeekPosition(10)
+	if position, _, _ := timeline.Position(); position != 2 {
+		t.Fatalf("SeekPosition past the end: position = %d, want 2", position)
+	}
+
+	timeline.Seek(-time.Second)
+	if elapsed := timeline.Elapsed(); elapsed != 0 {
+		t.Fatalf("negative Seek: elapsed = %v, want 0", elapsed)
+	}
+}
+
+func TestTimelineSpeed(t *testing.T) {
+	clock := newFakeClock()
+	timeline := NewTimeline(clock, []time.Duration{ms(100), ms(100)}, 0)
+
+	clock.Advance(ms(50))
+	timeline.SetSpeed(2)
+	clock.Advance(ms(25))
+
+	if elapsed := timeline.Elapsed(); elapsed != ms(100) {
+		t.Fatalf("elapsed at double speed = %v, want 100ms", elapsed)
+	}
+	if position, _, next := timeline.Position(); position != 1 || next != ms(50) {
+		t.Fatalf("at double speed: got (%d, %v), want (1, 50ms)", position, next)
+	}
+
+	clock.Advance(ms(10))
+	if _, _, next := timeline.Position(); next != ms(40) {
+		t.Fatalf("wall time to next frame at double speed = %v, want 40ms", next)
+	}
+
+	timeline.SetSpeed(0)
+	if speed := timeline.Speed(); speed != 1 {
+		t.Fatalf("SetSpeed(0) = %v, want fallback to 1", speed)
+	}
+}
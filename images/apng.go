@@ -0,0 +1,287 @@
+package images
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"image"
+	"image/draw"
+	"image/png"
+)
+
+const (
+	apngDisposeNone       = 0
+	apngDisposeBackground = 1
+	apngDisposePrevious   = 2
+
+	apngBlendSource = 0
+	apngBlendOver   = 1
+)
+
+var pngSignature = []byte("\x89PNG\r\n\x1a\n")
+
+type Animation struct {
+	Frames []*image.RGBA
+	Delays []int
+	Plays  int
+}
+
+type pngChunk struct {
+	kind string
+	data []byte
+}
+
+type apngFrame struct {
+	width, height    int
+	xOffset, yOffset int
+	delay            int
+	dispose          byte
+	blend            byte
+	data             [][]byte
+}
+
+func IsAPNG(data []byte) bool {
+	if !bytes.HasPrefix(data, pngSignature) {
+		return false
+	}
+
+	chunks, err := readPNGChunks(data)
+	if err != nil {
+		return false
+	}
+
+	for _, chunk := range chunks {
+		switch chunk.kind {
+		case "acTL":
+			return true
+		case "IDAT":
+			return false
+		}
+	}
+	return false
+}
+
+func DecodeAPNG(data []byte) (*Animation, error) {
+	if !bytes.HasPrefix(data, pngSignature) {
+		return nil, errors.New("apng: invalid signature")
+	}
+
+	chunks, err := readPNGChunks(data)
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		header  []byte
+		shared  []pngChunk
+		frames  []*apngFrame
+		current *apngFrame
+		plays   int
+		actl    bool
+	)
+
+	for _, chunk := range chunks {
+		switch chunk.kind {
+		case "IHDR":
+			if len(chunk.data) != 13 {
+				return nil, errors.New("apng: invalid IHDR chunk")
+			}
+			header = chunk.data
+		case "PLTE", "tRNS", "gAMA", "sRGB", "iCCP", "cHRM", "sBIT":
+			shared = append(shared, chunk)
+		case "acTL":
+			if len(chunk.data) != 8 {
+				return nil, errors.New("apng: invalid acTL chunk")
+			}
+			plays = int(binary.BigEndian.Uint32(chunk.data[4:8]))
+			actl = true
+		case "fcTL":
+			frame, err := parseFrameControl(chunk.data)
+			if err != nil {
+				return nil, err
+			}
+			current = frame
+			frames = append(frames, frame)
+		case "IDAT":
+			if current != nil && len(frames) == 1 {
+				current.data = append(current.data, chunk.data)
+			}
+		case "fdAT":
+			if len(chunk.data) < 4 {
+				return nil, errors.New("apng: invalid fdAT chunk")
+			}
+			if current == nil {
+				return nil, errors.New("apng: fdAT chunk before fcTL")
+			}
+			current.data = append(current.data, chunk.data[4:])
+		}
+	}
+
+	if header == nil {
+		return nil, errors.New("apng: missing IHDR chunk")
+	}
+	if !actl || len(frames) == 0 {
+		return nil, errors.New("apng: missing animation control")
+	}
+
+	canvasWidth := int(binary.BigEndian.Uint32(header[0:4]))
+	canvasHeight := int(binary.BigEndian.Uint32(header[4:8]))
+	canvasBounds := image.Rect(0, 0, canvasWidth, canvasHeight)
+	canvas := image.NewRGBA(canvasBounds)
+
+	animation := &Animation{
+		Frames: make([]*image.RGBA, 0, len(frames)),
+		Delays: make([]int, 0, len(frames)),
+		Plays:  plays,
+	}
+
+	for i, frame := range frames {
+		if len(frame.data) == 0 {
+			return nil, fmt.Errorf("apng: frame %d has no image data", i)
+		}
+
+		img, err := decodeAPNGFrame(header, shared, frame)
+		if err != nil {
+			return nil, fmt.Errorf("apng: frame %d: %w", i, err)
+		}
+
+		region := image.Rect(frame.xOffset, frame.yOffset, frame.xOffset+frame.width, frame.yOffset+frame.height).Intersect(canvasBounds)
+
+		dispose := frame.dispose
+		if i == 0 && dispose == apngDisposePrevious {
+			dispose = apngDisposeBackground
+		}
+
+		var saved *image.RGBA
+		if dispose == apngDisposePrevious {
+			saved = image.NewRGBA(region)
+			draw.Draw(saved, region, canvas, region.Min, draw.Src)
+		}
+
+		op := draw.Over
+		if frame.blend == apngBlendSource {
+			op = draw.Src
+		}
+		draw.Draw(canvas, region, img, img.Bounds().Min, op)
+
+		frameImg := image.NewRGBA(canvasBounds)
+		copy(frameImg.Pix, canvas.Pix)
+		animation.Frames = append(animation.Frames, frameImg)
+		animation.Delays = append(animation.Delays, frame.delay)
+
+		switch dispose {
+		case apngDisposeBackground:
+			draw.Draw(canvas, region, image.Transparent, image.Point{}, draw.Src)
+		case apngDisposePrevious:
+			draw.Draw(canvas, region, saved, region.Min, draw.Src)
+		}
+	}
+
+	return animation, nil
+}
+
+func parseFrameControl(data []byte) (*apngFrame, error) {
+	if len(data) != 26 {
+		return nil, errors.New("apng: invalid fcTL chunk")
+	}
+
+	delayNum := int(binary.BigEndian.Uint16(data[20:22]))
+	delayDen := int(binary.BigEndian.Uint16(data[22:24]))
+	if delayDen == 0 {
+		delayDen = 100
+	}
+
+	frame := &apngFrame{
+		width:   int(binary.BigEndian.Uint32(data[4:8])),
+		height:  int(binary.BigEndian.Uint32(data[8:12])),
+		xOffset: int(binary.BigEndian.Uint32(data[12:16])),
+		yOffset: int(binary.BigEndian.Uint32(data[16:20])),
+		delay:   delayNum * 1000 / delayDen,
+		dispose: data[24],
+		blend:   data[25],
+	}
+
+	if frame.width == 0 || frame.height == 0 {
+		return nil, errors.New("apng: invalid frame size")
+	}
+	if frame.dispose > apngDisposePrevious || frame.blend > apngBlendOver {
+		return nil, errors.New("apng: invalid dispose or blend op")
+	}
+
+	return frame, nil
+}
+
+func decodeAPNGFrame(header []byte, shared []pngChunk, frame *apngFrame) (image.Image, error) {
+	var buf bytes.Buffer
+	buf.Write(pngSignature)
+
+	frameHeader := make([]byte, len(header))
+	copy(frameHeader, header)
+	binary.BigEndian.PutUint32(frameHeader[0:4], uint32(frame.width))
+	binary.BigEndian.PutUint32(frameHeader[4:8], uint32(frame.height))
+	writePNGChunk(&buf, "IHDR", frameHeader)
+
+	for _, chunk := range shared {
+		writePNGChunk(&buf, chunk.kind, chunk.data)
+	}
+
+	writePNGChunk(&buf, "IDAT", bytes.Join(frame.data, nil))
+	writePNGChunk(&buf, "IEND", nil)
+
+	img, err := png.Decode(&buf)
+	if err != nil {
+		return nil, err
+	}
+
+	return img, nil
+}
+
+func readPNGChunks(data []byte) ([]pngChunk, error) {
+	var chunks []pngChunk
+
+	offset := len(pngSignature)
+	for offset < len(data) {
+		if offset+8 > len(data) {
+			return nil, errors.New("png: truncated chunk header")
+		}
+
+		length := int(binary.BigEndian.Uint32(data[offset : offset+4]))
+		kind := string(data[offset+4 : offset+8])
+		end := offset + 8 + length + 4
+
+		if length < 0 || end > len(data) {
+			return nil, fmt.Errorf("png: truncated %s chunk", kind)
+		}
+
+		chunks = append(chunks, pngChunk{
+			kind: kind,
+			data: data[offset+8 : offset+8+length],
+		})
+
+		offset = end
+		if kind == "IEND" {
+			break
+		}
+	}
+
+	return chunks, nil
+}
+
+func writePNGChunk(buf *bytes.Buffer, kind string, data []byte) {
+	var length [4]byte
+	binary.BigEndian.PutUint32(length[:], uint32(len(data)))
+	buf.Write(length[:])
+
+	crc := crc32.NewIEEE()
+	crc.Write([]byte(kind))
+	crc.Write(data)
+
+	buf.WriteString(kind)
+	buf.Write(data)
+
+	var sum [4]byte
+	binary.BigEndian.PutUint32(sum[:], crc.Sum32())
+	buf.Write(sum[:])
+}
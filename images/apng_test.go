@@ -0,0 +1,110 @@
+package images
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+var apngGoldenTests = []struct {
+	name   string
+	delays []int
+	plays  int
+	checks []pixelCheck
+}{
+	{
+		name:   "apng-blend",
+		delays: []int{100, 100, 100},
+		checks: []pixelCheck{
+			{frame: 0, at: image.Pt(1, 1), want: redPixel},
+			{frame: 1, at: image.Pt(1, 1), want: greenPixel},
+			{frame: 1, at: image.Pt(2, 1), want: redPixel},
+			{frame: 1, at: image.Pt(1, 2), want: color.RGBA{R: 127, B: 128, A: 255}},
+			{frame: 2, at: image.Pt(1, 1), want: greenPixel},
+			{frame: 2, at: image.Pt(2, 1), want: clearPixel},
+			{frame: 2, at: image.Pt(1, 2), want: color.RGBA{B: 128, A: 128}},
+			{frame: 2, at: image.Pt(0, 0), want: redPixel},
+		},
+	},
+	{
+		name:   "apng-dispose",
+		delays: []int{100, 50, 100, 100, 100},
+		plays:  3,
+		checks: []pixelCheck{
+			{frame: 0, at: image.Pt(3, 3), want: redPixel},
+			{frame: 1, at: image.Pt(0, 0), want: greenPixel},
+			{frame: 1, at: image.Pt(3, 3), want: clearPixel},
+			{frame: 2, at: image.Pt(1, 1), want: greenPixel},
+			{frame: 2, at: image.Pt(3, 3), want: bluePixel},
+			{frame: 3, at: image.Pt(0, 0), want: greenPixel},
+			{frame: 3, at: image.Pt(1, 1), want: color.RGBA{R: 255, G: 255, B: 255, A: 255}},
+			{frame: 3, at: image.Pt(2, 2), want: color.RGBA{R: 255, G: 255, B: 255, A: 255}},
+			{frame: 3, at: image.Pt(3, 3), want: clearPixel},
+			{frame: 4, at: image.Pt(1, 1), want: greenPixel},
+			{frame: 4, at: image.Pt(2, 2), want: clearPixel},
+			{frame: 4, at: image.Pt(3, 0), want: redPixel},
+		},
+	},
+	{
+		name:   "apng-hidden-default",
+		delays: []int{100, 100},
+		checks: []pixelCheck{
+			{frame: 0, at: image.Pt(0, 0), want: greenPixel},
+			{frame: 0, at: image.Pt(3, 3), want: greenPixel},
+			{frame: 1, at: image.Pt(0, 0), want: redPixel},
+			{frame: 1, at: image.Pt(1, 1), want: greenPixel},
+		},
+	},
+	{
+		name:   "apng-offsets",
+		delays: []int{100, 100, 100},
+		checks: []pixelCheck{
+			{frame: 1, at: image.Pt(1, 1), want: redPixel},
+			{frame: 1, at: image.Pt(2, 2), want: greenPixel},
+			{frame: 1, at: image.Pt(3, 3), want: greenPixel},
+			{frame: 2, at: image.Pt(3, 3), want: greenPixel},
+			{frame: 2, at: image.Pt(0, 0), want: redPixel},
+		},
+	},
+}
+
+func TestDecodeAPNGGolden(t *testing.T) {
+	for _, tt := range apngGoldenTests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, err := os.ReadFile(filepath.Join("testdata", tt.name+".png"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !IsAPNG(data) {
+				t.Fatal("fixture not detected as APNG")
+			}
+
+			animation, err := DecodeAPNG(data)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if fmt.Sprint(animation.Delays) != fmt.Sprint(tt.delays) || animation.Plays != tt.plays {
+				t.Fatalf("delays = %v, plays = %d, want %v, %d", animation.Delays, animation.Plays, tt.delays, tt.plays)
+			}
+			for i, frame := range animation.Frames {
+				if frame.Bounds() != image.Rect(0, 0, 4, 4) {
+					t.Fatalf("frame %d bounds = %v, want the 4x4 canvas", i, frame.Bounds())
+				}
+			}
+
+			for _, check := range tt.checks {
+				if got := animation.Frames[check.frame].RGBAAt(check.at.X, check.at.Y); got != check.want {
+					t.Errorf("frame %d at %v = %v, want %v", check.frame, check.at, got, check.want)
+				}
+			}
+
+			for i, frame := range animation.Frames {
+				compareGolden(t, filepath.Join("testdata", fmt.Sprintf("%s.%d.png", tt.name, i)), frame)
+			}
+		})
+	}
+}
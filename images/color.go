@@ -0,0 +1,265 @@
+package images
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"math"
+	"strconv"
+	"strings"
+)
+
+const linearLevels = 4096
+
+var (
+	srgbToLinear [256]float64
+	linearToSRGB [linearLevels + 1]uint8
+)
+
+func init() {
+	for i := range srgbToLinear {
+		c := float64(i) / 255
+		if c <= 0.04045 {
+			srgbToLinear[i] = c / 12.92
+		} else {
+			srgbToLinear[i] = math.Pow((c+0.055)/1.055, 2.4)
+		}
+	}
+
+	for i := range linearToSRGB {
+		c := float64(i) / linearLevels
+		if c <= 0.0031308 {
+			c *= 12.92
+		} else {
+			c = 1.055*math.Pow(c, 1/2.4) - 0.055
+		}
+		linearToSRGB[i] = uint8(math.Round(c * 255))
+	}
+}
+
+func toSRGB(c float64) uint8 {
+	if c <= 0 {
+		return 0
+	}
+	if c >= 1 {
+		return 255
+	}
+	return linearToSRGB[int(c*linearLevels+0.5)]
+}
+
+func luminance(c [3]float64) float64 {
+	return 0.2126*c[0] + 0.7152*c[1] + 0.0722*c[2]
+}
+
+func ParseColor(s string) (color.NRGBA, error) {
+	hex := strings.TrimPrefix(strings.TrimSpace(s), "#")
+
+	switch len(hex) {
+	case 3, 4:
+		expanded := make([]byte, 0, len(hex)*2)
+		for i := range len(hex) {
+			expanded = append(expanded, hex[i], hex[i])
+		}
+		hex = string(expanded)
+	case 6, 8:
+	default:
+		return color.NRGBA{}, fmt.Errorf("invalid color %q", s)
+	}
+	if len(hex) == 6 {
+		hex += "ff"
+	}
+
+	value, err := strconv.ParseUint(hex, 16, 32)
+	if err != nil {
+		return color.NRGBA{}, fmt.Errorf("invalid color %q", s)
+	}
+
+	return color.NRGBA{
+		R: uint8(value >> 24),
+		G: uint8(value >> 16),
+		B: uint8(value >> 8),
+		A: uint8(value),
+	}, nil
+}
+
+func adjustColor(i *image.RGBA, adjust func(c *[3]float64, alpha *float64)) *image.RGBA {
+	bounds := i.Bounds()
+	width, height := bounds.Dx(), bounds.Dy()
+	dst := image.NewRGBA(bounds)
+
+	parallel(height, bandCount(height), func(_, from, to int) {
+		for y := from; y < to; y++ {
+			offset := i.PixOffset(bounds.Min.X, bounds.Min.Y+y)
+			src := i.Pix[offset : offset+width*4]
+			out := dst.Pix[y*dst.Stride : y*dst.Stride+width*4]
+
+			for x := 0; x < len(src); x += 4 {
+				a := src[x+3]
+				if a == 0 {
+					continue
+				}
+
+				var c [3]float64
+				for k := range c {
+					c[k] = srgbToLinear[min((int(src[x+k])*255+int(a)/2)/int(a), 255)]
+				}
+				alpha := float64(a) / 255
+
+				adjust(&c, &alpha)
+
+				alpha = min(max(alpha, 0), 1)
+				out[x+3] = uint8(math.Round(alpha * 255))
+				for k := range c {
+					out[x+k] = uint8(math.Round(float64(toSRGB(c[k])) * alpha))
+				}
+			}
+		}
+	})
+
+	return dst
+}
+
+type Opacity struct {
+	Amount float64
+}
+
+func (o Opacity) Apply(i *image.RGBA) *image.RGBA {
+	return adjustColor(i, func(_ *[3]float64, alpha *float64) {
+		*alpha *= o.Amount
+	})
+}
+
+func (o Opacity) String() string {
+	return fmt.Sprintf("opacity(%g)", o.Amount)
+}
+
+type Brightness struct {
+	Amount float64
+}
+
+func (b Brightness) Apply(i *image.RGBA) *image.RGBA {
+	return adjustColor(i, func(c *[3]float64, _ *float64) {
+		for k := range c {
+			c[k] *= b.Amount
+		}
+	})
+}
+
+func (b Brightness) String() string {
+	return fmt.Sprintf("brightness(%g)", b.Amount)
+}
+
+type Contrast struct {
+	Amount float64
+}
+
+func (ct Contrast) Apply(i *image.RGBA) *image.RGBA {
+	pivot := srgbToLinear[128]
+	return adjustColor(i, func(c *[3]float64, _ *float64) {
+		for k := range c {
+			c[k] = (c[k]-pivot)*ct.Amount + pivot
+		}
+	})
+}
+
+func (ct Contrast) String() string {
+	return fmt.Sprintf("contrast(%g)", ct.Amount)
+}
+
+type Saturation struct {
+	Amount float64
+}
+
+func (s Saturation) Apply(i *image.RGBA) *image.RGBA {
+	return adjustColor(i, func(c *[3]float64, _ *float64) {
+		saturate(c, s.Amount)
+	})
+}
+
+func (s Saturation) String() string {
+	return fmt.Sprintf("saturation(%g)", s.Amount)
+}
+
+type Grayscale struct {
+	Amount float64
+}
+
+func (g Grayscale) Apply(i *image.RGBA) *image.RGBA {
+	return adjustColor(i, func(c *[3]float64, _ *float64) {
+		saturate(c, 1-min(max(g.Amount, 0), 1))
+	})
+}
+
+func (g Grayscale) String() string {
+	return fmt.Sprintf("grayscale(%g)", g.Amount)
+}
+
+type HueRotate struct {
+	Degrees float64
+}
+
+func (h HueRotate) Apply(i *image.RGBA) *image.RGBA {
+	sin, cos := math.Sincos(h.Degrees * math.Pi / 180)
+
+	m := [9]float64{
+		0.213 + cos*0.787 - sin*0.213, 0.715 - cos*0.715 - sin*0.715, 0.072 - cos*0.072 + sin*0.928,
+		0.213 - cos*0.213 + sin*0.143, 0.715 + cos*0.285 + sin*0.140, 0.072 - cos*0.072 - sin*0.283,
+		0.213 - cos*0.213 - sin*0.787, 0.715 - cos*0.715 + sin*0.715, 0.072 + cos*0.928 + sin*0.072,
+	}
+
+	return adjustColor(i, func(c *[3]float64, _ *float64) {
+		r, g, b := c[0], c[1], c[2]
+		c[0] = m[0]*r + m[1]*g + m[2]*b
+		c[1] = m[3]*r + m[4]*g + m[5]*b
+		c[2] = m[6]*r + m[7]*g + m[8]*b
+	})
+}
+
+func (h HueRotate) String() string {
+	return fmt.Sprintf("hue-rotate(%g)", h.Degrees)
+}
+
+type Invert struct {
+	Amount float64
+}
+
+func (iv Invert) Apply(i *image.RGBA) *image.RGBA {
+	amount := min(max(iv.Amount, 0), 1)
+	return adjustColor(i, func(c *[3]float64, _ *float64) {
+		for k := range c {
+			c[k] += (1 - 2*c[k]) * amount
+		}
+	})
+}
+
+func (iv Invert) String() string {
+	return fmt.Sprintf("invert(%g)", iv.Amount)
+}
+
+type Tint struct {
+	Color  color.NRGBA
+	Amount float64
+}
+
+func (t Tint) Apply(i *image.RGBA) *image.RGBA {
+	amount := min(max(t.Amount, 0), 1)
+	tint := [3]float64{srgbToLinear[t.Color.R], srgbToLinear[t.Color.G], srgbToLinear[t.Color.B]}
+
+	return adjustColor(i, func(c *[3]float64, _ *float64) {
+		y := luminance(*c)
+		for k := range c {
+			c[k] += (y*tint[k] - c[k]) * amount
+		}
+	})
+}
+
+func (t Tint) String() string {
+	return fmt.Sprintf("tint(#%02x%02x%02x,%g)", t.Color.R, t.Color.G, t.Color.B, t.Amount)
+}
+
+func saturate(c *[3]float64, amount float64) {
+	y := luminance(*c)
+	for k := range c {
+		c[k] = y + (c[k]-y)*amount
+	}
+}
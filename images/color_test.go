@@ -0,0 +1,77 @@
+package images
+
+import (
+	"image"
+	"image/color"
+	"testing"
+)
+
+func TestColorFilters(t *testing.T) {
+	red := color.RGBA{R: 255, A: 255}
+	gray := color.RGBA{R: 128, G: 128, B: 128, A: 255}
+	half := color.RGBA{R: 100, G: 50, A: 128}
+
+	tests := []struct {
+		transform Transform
+		want      [3]color.RGBA
+	}{
+		{transform: Opacity{Amount: 0.5}, want: [3]color.RGBA{{R: 128, A: 128}, {R: 64, G: 64, B: 64, A: 128}, {R: 50, G: 25, A: 64}}},
+		{transform: Brightness{Amount: 0}, want: [3]color.RGBA{{A: 255}, {A: 255}, {A: 128}}},
+		{transform: Brightness{Amount: 2}, want: [3]color.RGBA{red, {R: 176, G: 176, B: 176, A: 255}, {R: 128, G: 69, A: 128}}},
+		{transform: Contrast{Amount: 0}, want: [3]color.RGBA{gray, gray, {R: 64, G: 64, B: 64, A: 128}}},
+		{transform: Contrast{Amount: 1}, want: [3]color.RGBA{red, gray, half}},
+		{transform: Saturation{Amount: 1}, want: [3]color.RGBA{red, gray, half}},
+		{transform: Saturation{Amount: 0}, want: [3]color.RGBA{{R: 127, G: 127, B: 127, A: 255}, gray, {R: 64, G: 64, B: 64, A: 128}}},
+		{transform: Grayscale{Amount: 1}, want: [3]color.RGBA{{R: 127, G: 127, B: 127, A: 255}, gray, {R: 64, G: 64, B: 64, A: 128}}},
+		{transform: HueRotate{Degrees: 0}, want: [3]color.RGBA{red, gray, half}},
+		{transform: HueRotate{Degrees: 360}, want: [3]color.RGBA{red, gray, half}},
+		{transform: HueRotate{Degrees: 120}, want: [3]color.RGBA{{G: 178, A: 255}, gray, {G: 79, B: 12, A: 128}}},
+		{transform: Invert{Amount: 1}, want: [3]color.RGBA{{G: 255, B: 255, A: 255}, {R: 229, G: 229, B: 229, A: 255}, {R: 88, G: 120, B: 128, A: 128}}},
+		{transform: Invert{Amount: 0.5}, want: [3]color.RGBA{{R: 188, G: 188, B: 188, A: 255}, {R: 188, G: 188, B: 188, A: 255}, {R: 94, G: 94, B: 94, A: 128}}},
+		{transform: Tint{Color: color.NRGBA{B: 255, A: 255}, Amount: 1}, want: [3]color.RGBA{{B: 127, A: 255}, {B: 128, A: 255}, {B: 64, A: 128}}},
+	}
+
+	for _, tt := range tests {
+		for i, c := range []color.RGBA{red, gray, half} {
+			src := image.NewRGBA(image.Rect(0, 0, 2, 1))
+			src.SetRGBA(0, 0, c)
+
+			got := tt.transform.Apply(src)
+			if pixel := got.RGBAAt(0, 0); pixel != tt.want[i] {
+				t.Errorf("%s of %v = %v, want %v", tt.transform, c, pixel, tt.want[i])
+			}
+			if pixel := got.RGBAAt(1, 0); pixel != clearPixel {
+				t.Errorf("%s made a transparent pixel %v", tt.transform, pixel)
+			}
+		}
+	}
+}
+
+func TestParseColor(t *testing.T) {
+	tests := []struct {
+		input string
+		want  color.NRGBA
+		err   bool
+	}{
+		{input: "#ff8000", want: color.NRGBA{R: 255, G: 128, A: 255}},
+		{input: " ff800080 ", want: color.NRGBA{R: 255, G: 128, A: 128}},
+		{input: "#f80", want: color.NRGBA{R: 255, G: 136, A: 255}},
+		{input: "#f808", want: color.NRGBA{R: 255, G: 136, A: 136}},
+		{input: "#12", err: true},
+		{input: "#gggggg", err: true},
+		{input: "red", err: true},
+	}
+
+	for _, tt := range tests {
+		got, err := ParseColor(tt.input)
+		if tt.err {
+			if err == nil {
+				t.Errorf("ParseColor(%q) = %v, want an error", tt.input, got)
+			}
+			continue
+		}
+		if err != nil || got != tt.want {
+			t.Errorf("ParseColor(%q) = %v, %v, want %v", tt.input, got, err, tt.want)
+		}
+	}
+}
@@ -0,0 +1,298 @@
+package images
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"math"
+)
+
+type margin struct {
+	left, top, right, bottom int
+}
+
+type Outline struct {
+	Width int
+	Color color.NRGBA
+}
+
+func (o Outline) Apply(i *image.RGBA) *image.RGBA {
+	if o.Width <= 0 {
+		return i
+	}
+
+	m := margin{o.Width + 1, o.Width + 1, o.Width + 1, o.Width + 1}
+	alpha, width, height := expandAlpha(i, m)
+	distance := distanceField(alpha, width, height)
+
+	for k, d := range distance {
+		alpha[k] = float32(min(max(float64(o.Width)+1-d, 0), 1))
+	}
+
+	return composite(i, m, alpha, o.Color)
+}
+
+func (o Outline) String() string {
+	return fmt.Sprintf("outline(%d,%s)", o.Width, hexColor(o.Color))
+}
+
+type DropShadow struct {
+	OffsetX, OffsetY int
+	Blur             float64
+	Color            color.NRGBA
+}
+
+func (d DropShadow) Apply(i *image.RGBA) *image.RGBA {
+	spread := blurRadius(d.Blur)
+	m := margin{
+		left:   max(spread-d.OffsetX, 0),
+		top:    max(spread-d.OffsetY, 0),
+		right:  max(spread+d.OffsetX, 0),
+		bottom: max(spread+d.OffsetY, 0),
+	}
+
+	alpha, width, height := expandAlpha(i, m)
+	shadow := make([]float32, len(alpha))
+	for y := range height {
+		sy := y - d.OffsetY
+		if sy < 0 || sy >= height {
+			continue
+		}
+		for x := range width {
+			sx := x - d.OffsetX
+			if sx >= 0 && sx < width {
+				shadow[y*width+x] = alpha[sy*width+sx]
+			}
+		}
+	}
+
+	return composite(i, m, blurAlpha(shadow, width, height, d.Blur), d.Color)
+}
+
+func (d DropShadow) String() string {
+	return fmt.Sprintf("drop-shadow(%d,%d,%g,%s)", d.OffsetX, d.OffsetY, d.Blur, hexColor(d.Color))
+}
+
+type Glow struct {
+	Radius   float64
+	Strength float64
+	Color    color.NRGBA
+}
+
+func (g Glow) Apply(i *image.RGBA) *image.RGBA {
+	if g.Radius <= 0 {
+		return i
+	}
+
+	sigma := g.Radius / 2
+	spread := blurRadius(sigma)
+	m := margin{spread, spread, spread, spread}
+
+	alpha, width, height := expandAlpha(i, m)
+	glow := blurAlpha(alpha, width, height, sigma)
+	for k, a := range glow {
+		glow[k] = min(a*float32(g.Strength), 1)
+	}
+
+	return composite(i, m, glow, g.Color)
+}
+
+func (g Glow) String() string {
+	return fmt.Sprintf("glow(%g,%g,%s)", g.Radius, g.Strength, hexColor(g.Color))
+}
+
+func expandAlpha(i *image.RGBA, m margin) ([]float32, int, int) {
+	bounds := i.Bounds()
+	width := bounds.Dx() + m.left + m.right
+	height := bounds.Dy() + m.top + m.bottom
+
+	alpha := make([]float32, width*height)
+	for y := range bounds.Dy() {
+		offset := i.PixOffset(bounds.Min.X, bounds.Min.Y+y)
+		row := alpha[(y+m.top)*width+m.left:]
+		for x := range bounds.Dx() {
+			row[x] = float32(i.Pix[offset+x*4+3]) / 255
+		}
+	}
+
+	return alpha, width, height
+}
+
+func composite(i *image.RGBA, m margin, layer []float32, c color.NRGBA) *image.RGBA {
+	bounds := i.Bounds()
+	rect := image.Rect(
+		bounds.Min.X-m.left,
+		bounds.Min.Y-m.top,
+		bounds.Max.X+m.right,
+		bounds.Max.Y+m.bottom,
+	)
+	width := rect.Dx()
+
+	dst := image.NewRGBA(rect)
+	strength := float32(c.A) / 255
+
+	parallel(rect.Dy(), bandCount(rect.Dy()), func(_, from, to int) {
+		for y := from; y < to; y++ {
+			out := dst.Pix[y*dst.Stride : y*dst.Stride+width*4]
+
+			for x := range width {
+				a := layer[y*width+x] * strength
+				if a <= 0 {
+					continue
+				}
+				p := out[x*4 : x*4+4 : x*4+4]
+				p[0] = uint8(float32(c.R)*a + 0.5)
+				p[1] = uint8(float32(c.G)*a + 0.5)
+				p[2] = uint8(float32(c.B)*a + 0.5)
+				p[3] = uint8(255*a + 0.5)
+			}
+
+			sy := rect.Min.Y + y
+			if sy < bounds.Min.Y || sy >= bounds.Max.Y {
+				continue
+			}
+
+			src := i.Pix[i.PixOffset(bounds.Min.X, sy):]
+			row := out[m.left*4:]
+			for x := range bounds.Dx() {
+				s := src[x*4 : x*4+4 : x*4+4]
+				p := row[x*4 : x*4+4 : x*4+4]
+				inverse := 255 - uint32(s[3])
+				p[0] = s[0] + uint8((uint32(p[0])*inverse+127)/255)
+				p[1] = s[1] + uint8((uint32(p[1])*inverse+127)/255)
+				p[2] = s[2] + uint8((uint32(p[2])*inverse+127)/255)
+				p[3] = s[3] + uint8((uint32(p[3])*inverse+127)/255)
+			}
+		}
+	})
+
+	return dst
+}
+
+func blurRadius(sigma float64) int {
+	if sigma <= 0 {
+		return 0
+	}
+	return int(math.Ceil(sigma * 3))
+}
+
+func blurAlpha(alpha []float32, width, height int, sigma float64) []float32 {
+	radius := blurRadius(sigma)
+	if radius == 0 {
+		return alpha
+	}
+
+	weights := make([]float32, radius*2+1)
+	var total float32
+	for k := range weights {
+		x := float64(k - radius)
+		weights[k] = float32(math.Exp(-x * x / (2 * sigma * sigma)))
+		total += weights[k]
+	}
+	for k := range weights {
+		weights[k] /= total
+	}
+
+	temp := make([]float32, len(alpha))
+	parallel(height, bandCount(height), func(_, from, to int) {
+		for y := from; y < to; y++ {
+			row := alpha[y*width : (y+1)*width]
+			out := temp[y*width : (y+1)*width]
+			for x := range width {
+				var sum float32
+				for k, weight := range weights {
+					if sx := x + k - radius; sx >= 0 && sx < width {
+						sum += row[sx] * weight
+					}
+				}
+				out[x] = sum
+			}
+		}
+	})
+
+	blurred := make([]float32, len(alpha))
+	parallel(height, bandCount(height), func(_, from, to int) {
+		for y := from; y < to; y++ {
+			out := blurred[y*width : (y+1)*width]
+			for k, weight := range weights {
+				sy := y + k - radius
+				if sy < 0 || sy >= height {
+					continue
+				}
+				for x, value := range temp[sy*width : (sy+1)*width] {
+					out[x] += value * weight
+				}
+			}
+		}
+	})
+
+	return blurred
+}
+
+func distanceField(alpha []float32, width, height int) []float64 {
+	const inf = 1e20
+
+	field := make([]float64, len(alpha))
+	for k, a := range alpha {
+		if a >= 0.5 {
+			field[k] = 0
+		} else {
+			field[k] = inf
+		}
+	}
+
+	column := make([]float64, height)
+	out := make([]float64, max(width, height))
+	for x := range width {
+		for y := range height {
+			column[y] = field[y*width+x]
+		}
+		squaredDistance(column, out[:height])
+		for y := range height {
+			field[y*width+x] = out[y]
+		}
+	}
+
+	for y := range height {
+		row := field[y*width : (y+1)*width]
+		squaredDistance(row, out[:width])
+		for x := range width {
+			row[x] = math.Sqrt(out[x])
+		}
+	}
+
+	return field
+}
+
+func squaredDistance(f, d []float64) {
+	n := len(f)
+	v := make([]int, n)
+	z := make([]float64, n+1)
+
+	k := 0
+	z[0], z[1] = math.Inf(-1), math.Inf(1)
+
+	for q := 1; q < n; q++ {
+		s := ((f[q] + float64(q*q)) - (f[v[k]] + float64(v[k]*v[k]))) / float64(2*q-2*v[k])
+		for s <= z[k] {
+			k--
+			s = ((f[q] + float64(q*q)) - (f[v[k]] + float64(v[k]*v[k]))) / float64(2*q-2*v[k])
+		}
+		k++
+		v[k] = q
+		z[k], z[k+1] = s, math.Inf(1)
+	}
+
+	k = 0
+	for q := range n {
+		for z[k+1] < float64(q) {
+			k++
+		}
+		dq := float64(q - v[k])
+		d[q] = dq*dq + f[v[k]]
+	}
+}
+
+func hexColor(c color.NRGBA) string {
+	return fmt.Sprintf("#%02x%02x%02x%02x", c.R, c.G, c.B, c.A)
+}
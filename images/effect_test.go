@@ -0,0 +1,116 @@
+package images
+
+import (
+	"image"
+	"image/color"
+	"testing"
+)
+
+func redSquare() *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, 2, 2))
+	for y := range 2 {
+		for x := range 2 {
+			img.SetRGBA(x, y, redPixel)
+		}
+	}
+	return img
+}
+
+func TestOutline(t *testing.T) {
+	got := Outline{Width: 2, Color: color.NRGBA{B: 255, A: 255}}.Apply(redSquare())
+
+	if got.Bounds() != image.Rect(-3, -3, 5, 5) {
+		t.Fatalf("bounds = %v, want the image grown by width+1 on every side", got.Bounds())
+	}
+
+	checks := map[image.Point]color.RGBA{
+		image.Pt(0, 0):   redPixel,
+		image.Pt(1, 1):   redPixel,
+		image.Pt(-1, 0):  bluePixel,
+		image.Pt(-2, 1):  bluePixel,
+		image.Pt(3, 0):   bluePixel,
+		image.Pt(-1, -1): bluePixel,
+		image.Pt(-2, -1): {B: 195, A: 195},
+		image.Pt(-2, -2): {B: 44, A: 44},
+		image.Pt(-3, 0):  clearPixel,
+		image.Pt(4, 4):   clearPixel,
+	}
+	for at, want := range checks {
+		if pixel := got.RGBAAt(at.X, at.Y); pixel != want {
+			t.Errorf("pixel %v = %v, want %v", at, pixel, want)
+		}
+	}
+
+	if src := redSquare(); (Outline{}).Apply(src) != src {
+		t.Error("outline of width 0 did not return the image unchanged")
+	}
+}
+
+func TestDropShadow(t *testing.T) {
+	black := color.RGBA{A: 255}
+	got := DropShadow{OffsetX: 3, OffsetY: 1, Color: color.NRGBA{A: 255}}.Apply(redSquare())
+
+	if got.Bounds() != image.Rect(0, 0, 5, 3) {
+		t.Fatalf("bounds = %v, want the image grown towards the offset only", got.Bounds())
+	}
+
+	checks := map[image.Point]color.RGBA{
+		image.Pt(0, 0): redPixel,
+		image.Pt(1, 1): redPixel,
+		image.Pt(3, 1): black,
+		image.Pt(4, 2): black,
+		image.Pt(2, 0): clearPixel,
+		image.Pt(3, 0): clearPixel,
+		image.Pt(0, 2): clearPixel,
+	}
+	for at, want := range checks {
+		if pixel := got.RGBAAt(at.X, at.Y); pixel != want {
+			t.Errorf("pixel %v = %v, want %v", at, pixel, want)
+		}
+	}
+
+	left := DropShadow{OffsetX: -2, Blur: 1, Color: color.NRGBA{A: 128}}.Apply(redSquare())
+	if left.Bounds().Min.X >= -2 || left.RGBAAt(0, 0) != redPixel {
+		t.Errorf("shadow to the left gave %v with %v at the origin", left.Bounds(), left.RGBAAt(0, 0))
+	}
+	for y := left.Rect.Min.Y; y < left.Rect.Max.Y; y++ {
+		for x := left.Rect.Min.X; x < 0; x++ {
+			if a := left.RGBAAt(x, y).A; a > 128 {
+				t.Fatalf("shadow pixel (%d, %d) alpha %d exceeds the shadow colour alpha", x, y, a)
+			}
+		}
+	}
+}
+
+func TestGlow(t *testing.T) {
+	got := Glow{Radius: 2, Strength: 1, Color: color.NRGBA{R: 255, G: 255, B: 255, A: 255}}.Apply(redSquare())
+
+	bounds := got.Bounds()
+	if bounds.Min != image.Pt(-3, -3) || bounds.Max != image.Pt(5, 5) {
+		t.Fatalf("bounds = %v, want the image grown evenly", bounds)
+	}
+	if got.RGBAAt(0, 0) != redPixel || got.RGBAAt(1, 1) != redPixel {
+		t.Fatal("glow covered the image")
+	}
+
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			pixel, mirrored := got.RGBAAt(x, y), got.RGBAAt(1-x, 1-y)
+			if pixel != mirrored {
+				t.Fatalf("pixel (%d, %d) = %v, mirrored %v, want a symmetric glow", x, y, pixel, mirrored)
+			}
+			if pixel.R != pixel.A && (x < 0 || x > 1 || y < 0 || y > 1) {
+				t.Fatalf("glow pixel (%d, %d) = %v, want white premultiplied by its alpha", x, y, pixel)
+			}
+		}
+	}
+
+	for x := -1; x > bounds.Min.X; x-- {
+		if inner, outer := got.RGBAAt(x, 0).A, got.RGBAAt(x-1, 0).A; outer > inner {
+			t.Fatalf("glow alpha rises from %d to %d moving away at x %d", inner, outer, x)
+		}
+	}
+	if got.RGBAAt(-1, 0).A == 0 {
+		t.Fatal("no glow next to the image")
+	}
+}
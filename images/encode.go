@@ -0,0 +1,173 @@
+package images
+
+import (
+	"bytes"
+	"compress/zlib"
+	"encoding/binary"
+	"errors"
+	"image"
+	"image/color"
+	"image/color/palette"
+	"image/draw"
+	"image/gif"
+	"io"
+	"math"
+)
+
+const gifAlphaThreshold = 128
+
+func EncodeAPNG(w io.Writer, a *Animation) error {
+	if len(a.Frames) == 0 {
+		return errors.New("apng: animation has no frames")
+	}
+
+	bounds := a.Frames[0].Bounds()
+	var buf bytes.Buffer
+	buf.Write(pngSignature)
+
+	header := make([]byte, 13)
+	binary.BigEndian.PutUint32(header[0:4], uint32(bounds.Dx()))
+	binary.BigEndian.PutUint32(header[4:8], uint32(bounds.Dy()))
+	header[8] = 8
+	header[9] = 6
+	writePNGChunk(&buf, "IHDR", header)
+
+	control := make([]byte, 8)
+	binary.BigEndian.PutUint32(control[0:4], uint32(len(a.Frames)))
+	binary.BigEndian.PutUint32(control[4:8], uint32(max(a.Plays, 0)))
+	writePNGChunk(&buf, "acTL", control)
+
+	sequence := uint32(0)
+	for i, frame := range a.Frames {
+		if frame.Bounds().Size() != bounds.Size() {
+			return errors.New("apng: frames must share the same size")
+		}
+
+		delay := 0
+		if i < len(a.Delays) {
+			delay = a.Delays[i]
+		}
+
+		fctl := make([]byte, 26)
+		binary.BigEndian.PutUint32(fctl[0:4], sequence)
+		binary.BigEndian.PutUint32(fctl[4:8], uint32(bounds.Dx()))
+		binary.BigEndian.PutUint32(fctl[8:12], uint32(bounds.Dy()))
+		binary.BigEndian.PutUint16(fctl[20:22], uint16(min(max(delay, 0), math.MaxUint16)))
+		binary.BigEndian.PutUint16(fctl[22:24], 1000)
+		fctl[24] = apngDisposeNone
+		fctl[25] = apngBlendSource
+		writePNGChunk(&buf, "fcTL", fctl)
+		sequence++
+
+		data, err := compressScanlines(frame)
+		if err != nil {
+			return err
+		}
+
+		if i == 0 {
+			writePNGChunk(&buf, "IDAT", data)
+			continue
+		}
+
+		fdat := make([]byte, 4+len(data))
+		binary.BigEndian.PutUint32(fdat[0:4], sequence)
+		copy(fdat[4:], data)
+		writePNGChunk(&buf, "fdAT", fdat)
+		sequence++
+	}
+
+	writePNGChunk(&buf, "IEND", nil)
+
+	_, err := w.Write(buf.Bytes())
+	return err
+}
+
+func compressScanlines(frame *image.RGBA) ([]byte, error) {
+	bounds := frame.Bounds()
+	width := bounds.Dx()
+
+	var buf bytes.Buffer
+	z := zlib.NewWriter(&buf)
+
+	row := make([]byte, 1+width*4)
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		offset := frame.PixOffset(bounds.Min.X, y)
+		src := frame.Pix[offset : offset+width*4]
+		for x := 0; x < len(src); x += 4 {
+			c := color.NRGBAModel.Convert(color.RGBA{src[x], src[x+1], src[x+2], src[x+3]}).(color.NRGBA)
+			copy(row[1+x:1+x+4], []byte{c.R, c.G, c.B, c.A})
+		}
+		if _, err := z.Write(row); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := z.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func EncodeGIF(w io.Writer, a *Animation) error {
+	if len(a.Frames) == 0 {
+		return errors.New("gif: animation has no frames")
+	}
+
+	colors := append(color.Palette{color.RGBA{}}, palette.Plan9[:255]...)
+	bounds := a.Frames[0].Bounds()
+
+	g := &gif.GIF{
+		Config: image.Config{
+			ColorModel: colors,
+			Width:      bounds.Dx(),
+			Height:     bounds.Dy(),
+		},
+		LoopCount: gifLoopCount(a.Plays),
+	}
+
+	for i, frame := range a.Frames {
+		delay := 0
+		if i < len(a.Delays) {
+			delay = a.Delays[i]
+		}
+
+		g.Image = append(g.Image, quantizeGIFFrame(frame, colors))
+		g.Delay = append(g.Delay, max(int(math.Round(float64(delay)/10)), 1))
+		g.Disposal = append(g.Disposal, gif.DisposalBackground)
+	}
+
+	return gif.EncodeAll(w, g)
+}
+
+func gifLoopCount(plays int) int {
+	switch {
+	case plays <= 0:
+		return 0
+	case plays == 1:
+		return -1
+	}
+	return plays - 1
+}
+
+func quantizeGIFFrame(frame *image.RGBA, colors color.Palette) *image.Paletted {
+	bounds := frame.Bounds()
+	rect := image.Rect(0, 0, bounds.Dx(), bounds.Dy())
+
+	opaque := image.NewNRGBA(rect)
+	for y := range rect.Dy() {
+		offset := frame.PixOffset(bounds.Min.X, bounds.Min.Y+y)
+		src := frame.Pix[offset : offset+rect.Dx()*4]
+		dst := opaque.Pix[y*opaque.Stride:]
+		for x := 0; x < len(src); x += 4 {
+			if src[x+3] < gifAlphaThreshold {
+				continue
+			}
+			c := color.NRGBAModel.Convert(color.RGBA{src[x], src[x+1], src[x+2], src[x+3]}).(color.NRGBA)
+			dst[x], dst[x+1], dst[x+2], dst[x+3] = c.R, c.G, c.B, 255
+		}
+	}
+
+	paletted := image.NewPaletted(rect, colors)
+	draw.FloydSteinberg.Draw(paletted, rect, opaque, image.Point{})
+	return paletted
+}
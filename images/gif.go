@@ -0,0 +1,266 @@
+package images
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/gif"
+)
+
+func DecodeGIF(data []byte) (*Animation, error) {
+	g, err := gif.DecodeAll(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+
+	return &Animation{
+		Frames: CompositeGIF(g),
+		Delays: gifDelays(g),
+		Plays:  gifPlays(g.LoopCount),
+	}, nil
+}
+
+func gifDelays(g *gif.GIF) []int {
+	delays := make([]int, len(g.Image))
+	for i := range g.Image {
+		delays[i] = g.Delay[i] * 10
+	}
+	return delays
+}
+
+func gifPlays(loopCount int) int {
+	switch {
+	case loopCount == 0:
+		return 0
+	case loopCount < 0:
+		return 1
+	}
+	return loopCount + 1
+}
+
+func GIFCanvas(g *gif.GIF) image.Rectangle {
+	canvas := image.Rect(0, 0, g.Config.Width, g.Config.Height)
+	if !canvas.Empty() {
+		return canvas
+	}
+
+	for _, frame := range g.Image {
+		canvas = canvas.Union(frame.Bounds())
+	}
+	return image.Rect(0, 0, canvas.Max.X, canvas.Max.Y)
+}
+
+func CompositeGIF(g *gif.GIF) []*image.RGBA {
+	canvas := image.NewRGBA(GIFCanvas(g))
+	frames := make([]*image.RGBA, len(g.Image))
+
+	for i, frame := range g.Image {
+		disposal := byte(gif.DisposalNone)
+		if i < len(g.Disposal) {
+			disposal = g.Disposal[i]
+		}
+		frames[i] = image.NewRGBA(canvas.Bounds())
+		compositeGIFFrame(canvas, frame, disposal, frames[i])
+	}
+
+	return frames
+}
+
+func compositeGIFFrame(canvas *image.RGBA, frame *image.Paletted, disposal byte, out *image.RGBA) {
+	region := frame.Bounds().Intersect(canvas.Bounds())
+
+	var saved *image.RGBA
+	if disposal == gif.DisposalPrevious {
+		saved = image.NewRGBA(region)
+		draw.Draw(saved, region, canvas, region.Min, draw.Src)
+	}
+
+	drawPaletted(canvas, frame, region)
+
+	if out != nil {
+		copy(out.Pix, canvas.Pix)
+	}
+
+	switch disposal {
+	case gif.DisposalBackground:
+		draw.Draw(canvas, region, image.Transparent, image.Point{}, draw.Src)
+	case gif.DisposalPrevious:
+		draw.Draw(canvas, region, saved, region.Min, draw.Src)
+	}
+}
+
+func drawPaletted(dst *image.RGBA, src *image.Paletted, region image.Rectangle) {
+	palette := make([]color.RGBA, len(src.Palette))
+	for i, c := range src.Palette {
+		palette[i] = color.RGBAModel.Convert(c).(color.RGBA)
+	}
+
+	for y := region.Min.Y; y < region.Max.Y; y++ {
+		srcOffset := src.PixOffset(region.Min.X, y)
+		dstOffset := dst.PixOffset(region.Min.X, y)
+
+		for x := region.Min.X; x < region.Max.X; x++ {
+			index := int(src.Pix[srcOffset])
+			srcOffset++
+
+			if index < len(palette) && palette[index].A != 0 {
+				c := palette[index]
+				dst.Pix[dstOffset+0] = c.R
+				dst.Pix[dstOffset+1] = c.G
+				dst.Pix[dstOffset+2] = c.B
+				dst.Pix[dstOffset+3] = c.A
+			}
+			dstOffset += 4
+		}
+	}
+}
+
+type gifFrame struct {
+	bounds   image.Rectangle
+	delay    int
+	disposal byte
+	control  []byte
+	data     []byte
+}
+
+type gifIndex struct {
+	header    []byte
+	canvas    image.Rectangle
+	frames    []gifFrame
+	loopCount int
+}
+
+func indexGIF(data []byte) (*gifIndex, error) {
+	if len(data) < 13 || (string(data[:6]) != "GIF87a" && string(data[:6]) != "GIF89a") {
+		return nil, errors.New("gif: invalid signature")
+	}
+
+	offset := 13 + colorTableSize(data[10])
+	if offset > len(data) {
+		return nil, errors.New("gif: truncated color table")
+	}
+
+	index := &gifIndex{
+		header:    data[:offset],
+		canvas:    image.Rect(0, 0, int(binary.LittleEndian.Uint16(data[6:8])), int(binary.LittleEndian.Uint16(data[8:10]))),
+		loopCount: -1,
+	}
+
+	var control []byte
+	for {
+		if offset >= len(data) {
+			return nil, errors.New("gif: missing trailer")
+		}
+
+		switch data[offset] {
+		case 0x21:
+			if offset+2 > len(data) {
+				return nil, errors.New("gif: truncated extension")
+			}
+			end, err := skipGIFBlocks(data, offset+2)
+			if err != nil {
+				return nil, err
+			}
+
+			extension := data[offset:end]
+			switch {
+			case extension[1] == 0xf9:
+				if len(extension) < 8 || extension[2] != 4 {
+					return nil, errors.New("gif: invalid graphic control extension")
+				}
+				control = extension
+			case extension[1] == 0xff && len(extension) >= 19 && string(extension[3:14]) == "NETSCAPE2.0" && extension[14] == 3 && extension[15] == 1:
+				index.loopCount = int(binary.LittleEndian.Uint16(extension[16:18]))
+			}
+			offset = end
+
+		case 0x2c:
+			if offset+10 > len(data) {
+				return nil, errors.New("gif: truncated image descriptor")
+			}
+			descriptor := data[offset+1 : offset+10]
+			x, y := int(binary.LittleEndian.Uint16(descriptor[0:2])), int(binary.LittleEndian.Uint16(descriptor[2:4]))
+			width, height := int(binary.LittleEndian.Uint16(descriptor[4:6])), int(binary.LittleEndian.Uint16(descriptor[6:8]))
+
+			start := offset + 10 + colorTableSize(descriptor[8]) + 1
+			if start > len(data) {
+				return nil, errors.New("gif: truncated image data")
+			}
+			end, err := skipGIFBlocks(data, start)
+			if err != nil {
+				return nil, err
+			}
+
+			frame := gifFrame{
+				bounds:  image.Rect(x, y, x+width, y+height),
+				control: control,
+				data:    data[offset:end],
+			}
+			if control != nil {
+				frame.disposal = (control[3] >> 2) & 7
+				frame.delay = int(binary.LittleEndian.Uint16(control[4:6])) * 10
+			}
+			index.frames = append(index.frames, frame)
+			control = nil
+			offset = end
+
+		case 0x3b:
+			if len(index.frames) == 0 {
+				return nil, errors.New("gif: missing image data")
+			}
+			if index.canvas.Empty() {
+				for _, frame := range index.frames {
+					index.canvas = index.canvas.Union(frame.bounds)
+				}
+				index.canvas = image.Rect(0, 0, index.canvas.Max.X, index.canvas.Max.Y)
+			}
+			return index, nil
+
+		default:
+			return nil, fmt.Errorf("gif: unknown block type: 0x%.2x", data[offset])
+		}
+	}
+}
+
+func colorTableSize(fields byte) int {
+	if fields&0x80 == 0 {
+		return 0
+	}
+	return 3 << (1 + fields&0x07)
+}
+
+func skipGIFBlocks(data []byte, offset int) (int, error) {
+	for offset < len(data) {
+		size := int(data[offset])
+		offset += 1 + size
+		if size == 0 {
+			return offset, nil
+		}
+	}
+	return 0, errors.New("gif: truncated data blocks")
+}
+
+func (x *gifIndex) memory() int64 {
+	return int64(x.canvas.Dx()) * int64(x.canvas.Dy()) * 4 * int64(len(x.frames))
+}
+
+func (x *gifIndex) decode(i int) (*image.Paletted, error) {
+	frame := x.frames[i]
+
+	var buf bytes.Buffer
+	buf.Grow(len(x.header) + len(frame.control) + len(frame.data) + 1)
+	buf.Write(x.header)
+	buf.Write(frame.control)
+	buf.Write(frame.data)
+	buf.WriteByte(0x3b)
+
+	img, err := gif.Decode(&buf)
+	if err != nil {
+		return nil, fmt.Errorf("gif: frame %d: %w", i, err)
+	}
+	return img.(*image.Paletted), nil
+}
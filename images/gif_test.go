@@ -0,0 +1,241 @@
+package images
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/gif"
+	"image/png"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+var update = flag.Bool("update", false, "rewrite golden images in testdata")
+
+var (
+	clearPixel = color.RGBA{}
+	redPixel   = color.RGBA{R: 255, A: 255}
+	greenPixel = color.RGBA{G: 255, A: 255}
+	bluePixel  = color.RGBA{B: 255, A: 255}
+)
+
+type pixelCheck struct {
+	frame int
+	at    image.Point
+	want  color.RGBA
+}
+
+var gifGoldenTests = []struct {
+	name   string
+	canvas image.Rectangle
+	checks []pixelCheck
+}{
+	{
+		name:   "restore-previous",
+		canvas: image.Rect(0, 0, 4, 4),
+		checks: []pixelCheck{
+			{frame: 1, at: image.Pt(1, 1), want: bluePixel},
+			{frame: 1, at: image.Pt(0, 0), want: redPixel},
+			{frame: 2, at: image.Pt(0, 0), want: greenPixel},
+			{frame: 2, at: image.Pt(1, 1), want: redPixel},
+			{frame: 2, at: image.Pt(2, 2), want: redPixel},
+		},
+	},
+	{
+		name:   "dispose-background",
+		canvas: image.Rect(0, 0, 4, 4),
+		checks: []pixelCheck{
+			{frame: 1, at: image.Pt(3, 3), want: bluePixel},
+			{frame: 2, at: image.Pt(0, 0), want: greenPixel},
+			{frame: 2, at: image.Pt(1, 1), want: redPixel},
+			{frame: 2, at: image.Pt(1, 2), want: redPixel},
+			{frame: 2, at: image.Pt(2, 2), want: clearPixel},
+			{frame: 2, at: image.Pt(3, 3), want: clearPixel},
+		},
+	},
+	{
+		name:   "transparent-index",
+		canvas: image.Rect(0, 0, 4, 4),
+		checks: []pixelCheck{
+			{frame: 1, at: image.Pt(0, 0), want: redPixel},
+			{frame: 1, at: image.Pt(1, 0), want: bluePixel},
+			{frame: 1, at: image.Pt(3, 3), want: redPixel},
+		},
+	},
+	{
+		name:   "large-screen",
+		canvas: image.Rect(0, 0, 6, 6),
+		checks: []pixelCheck{
+			{frame: 0, at: image.Pt(0, 0), want: clearPixel},
+			{frame: 0, at: image.Pt(1, 1), want: greenPixel},
+			{frame: 0, at: image.Pt(5, 5), want: clearPixel},
+			{frame: 1, at: image.Pt(2, 2), want: greenPixel},
+			{frame: 1, at: image.Pt(5, 5), want: bluePixel},
+		},
+	},
+}
+
+func TestCompositeGIFGolden(t *testing.T) {
+	for _, tt := range gifGoldenTests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := loadTestGIF(t, tt.name)
+
+			if canvas := GIFCanvas(g); canvas != tt.canvas {
+				t.Fatalf("canvas = %v, want %v", canvas, tt.canvas)
+			}
+
+			frames := CompositeGIF(g)
+			for _, check := range tt.checks {
+				if got := frames[check.frame].RGBAAt(check.at.X, check.at.Y); got != check.want {
+					t.Errorf("frame %d at %v = %v, want %v", check.frame, check.at, got, check.want)
+				}
+			}
+
+			for i, frame := range frames {
+				compareGolden(t, filepath.Join("testdata", fmt.Sprintf("%s.%d.png", tt.name, i)), frame)
+			}
+		})
+	}
+}
+
+func TestStreamingGIFMatchesComposite(t *testing.T) {
+	for _, tt := range gifGoldenTests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := loadTestGIF(t, tt.name)
+			want := CompositeGIF(g)
+
+			data, err := os.ReadFile(filepath.Join("testdata", tt.name+".gif"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			stream, err := NewStreamingGIF(data, 1, 2)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if source, err := LoadGIF(data, 1); err != nil {
+				t.Fatal(err)
+			} else if _, ok := source.(*StreamingGIF); !ok {
+				t.Fatalf("LoadGIF over the memory limit returned %T", source)
+			}
+			if stream.FrameCount() != len(want) || stream.Canvas() != tt.canvas {
+				t.Fatalf("stream has %d frames on %v, want %d on %v", stream.FrameCount(), stream.Canvas(), len(want), tt.canvas)
+			}
+
+			for _, i := range []int{len(want) - 1, 0, 1, len(want) - 1} {
+				got, err := stream.Frame(i)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if !equalRGBA(got, want[i]) {
+					t.Errorf("frame %d differs from the composited frame", i)
+				}
+			}
+		})
+	}
+}
+
+func TestIndexGIFMatchesDecodeAll(t *testing.T) {
+	for _, tt := range gifGoldenTests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := loadTestGIF(t, tt.name)
+
+			data, err := os.ReadFile(filepath.Join("testdata", tt.name+".gif"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			index, err := indexGIF(data)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if len(index.frames) != len(g.Image) || index.canvas != GIFCanvas(g) || gifPlays(index.loopCount) != gifPlays(g.LoopCount) {
+				t.Fatalf("index has %d frames on %v playing %d times, want %d on %v playing %d times",
+					len(index.frames), index.canvas, gifPlays(index.loopCount), len(g.Image), GIFCanvas(g), gifPlays(g.LoopCount))
+			}
+
+			delays := gifDelays(g)
+			for i, frame := range index.frames {
+				if frame.delay != delays[i] || frame.disposal != g.Disposal[i] {
+					t.Errorf("frame %d delay %d disposal %d, want %d and %d", i, frame.delay, frame.disposal, delays[i], g.Disposal[i])
+				}
+
+				paletted, err := index.decode(i)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if paletted.Bounds() != g.Image[i].Bounds() || !bytes.Equal(paletted.Pix, g.Image[i].Pix) {
+					t.Errorf("frame %d decoded differently from DecodeAll", i)
+				}
+			}
+		})
+	}
+}
+
+func loadTestGIF(t *testing.T, name string) *gif.GIF {
+	t.Helper()
+
+	f, err := os.Open(filepath.Join("testdata", name+".gif"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	g, err := gif.DecodeAll(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return g
+}
+
+func compareGolden(t *testing.T, path string, got *image.RGBA) {
+	t.Helper()
+
+	if *update {
+		f, err := os.Create(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer f.Close()
+
+		if err := png.Encode(f, got); err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatalf("%v (run go test -update to create it)", err)
+	}
+	defer f.Close()
+
+	img, err := png.Decode(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := image.NewRGBA(img.Bounds())
+	draw.Draw(want, want.Bounds(), img, img.Bounds().Min, draw.Src)
+
+	if !equalRGBA(got, want) {
+		t.Errorf("%s: output differs from golden image", path)
+	}
+}
+
+func equalRGBA(a, b *image.RGBA) bool {
+	if a.Bounds() != b.Bounds() {
+		return false
+	}
+	for y := a.Rect.Min.Y; y < a.Rect.Max.Y; y++ {
+		for x := a.Rect.Min.X; x < a.Rect.Max.X; x++ {
+			if a.RGBAAt(x, y) != b.RGBAAt(x, y) {
+				return false
+			}
+		}
+	}
+	return true
+}
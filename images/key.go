@@ -0,0 +1,108 @@
+package images
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"math"
+)
+
+type ColorKey struct {
+	Color     color.NRGBA
+	Auto      bool
+	Tolerance float64
+	Feather   float64
+}
+
+func (k ColorKey) Apply(i *image.RGBA) *image.RGBA {
+	key := k.Color
+	if k.Auto {
+		key = CornerKey(i)
+	}
+
+	bounds := i.Bounds()
+	width, height := bounds.Dx(), bounds.Dy()
+	dst := image.NewRGBA(bounds)
+
+	keyColor := [3]float64{float64(key.R), float64(key.G), float64(key.B)}
+	tolerance := max(k.Tolerance, 0)
+	feather := max(k.Feather, 0)
+
+	parallel(height, bandCount(height), func(_, from, to int) {
+		for y := from; y < to; y++ {
+			offset := i.PixOffset(bounds.Min.X, bounds.Min.Y+y)
+			src := i.Pix[offset : offset+width*4]
+			out := dst.Pix[y*dst.Stride : y*dst.Stride+width*4]
+
+			for x := 0; x < len(src); x += 4 {
+				a := src[x+3]
+				if a == 0 {
+					continue
+				}
+
+				var sum float64
+				for c := range keyColor {
+					straight := float64(src[x+c]) * 255 / float64(a)
+					diff := straight - keyColor[c]
+					sum += diff * diff
+				}
+				distance := math.Sqrt(sum) / (255 * math.Sqrt(3))
+
+				factor := 1.0
+				switch {
+				case distance <= tolerance:
+					factor = 0
+				case distance < tolerance+feather:
+					factor = (distance - tolerance) / feather
+				}
+
+				for c := range 4 {
+					out[x+c] = uint8(math.Round(float64(src[x+c]) * factor))
+				}
+			}
+		}
+	})
+
+	return dst
+}
+
+func (k ColorKey) String() string {
+	key := hexColor(k.Color)
+	if k.Auto {
+		key = "auto"
+	}
+	return fmt.Sprintf("color-key(%s,%g,%g)", key, k.Tolerance, k.Feather)
+}
+
+func CornerKey(i *image.RGBA) color.NRGBA {
+	bounds := i.Bounds()
+	if bounds.Empty() {
+		return color.NRGBA{}
+	}
+
+	corners := [4]color.NRGBA{
+		nrgbaAt(i, bounds.Min.X, bounds.Min.Y),
+		nrgbaAt(i, bounds.Max.X-1, bounds.Min.Y),
+		nrgbaAt(i, bounds.Min.X, bounds.Max.Y-1),
+		nrgbaAt(i, bounds.Max.X-1, bounds.Max.Y-1),
+	}
+
+	best, votes := corners[0], 0
+	for _, candidate := range corners {
+		count := 0
+		for _, other := range corners {
+			if candidate == other {
+				count++
+			}
+		}
+		if count > votes {
+			best, votes = candidate, count
+		}
+	}
+
+	return best
+}
+
+func nrgbaAt(i *image.RGBA, x, y int) color.NRGBA {
+	return color.NRGBAModel.Convert(i.RGBAAt(x, y)).(color.NRGBA)
+}
@@ -0,0 +1,84 @@
+package images
+
+import (
+	"image"
+	"image/color"
+	"testing"
+)
+
+func TestColorKeyTolerance(t *testing.T) {
+	src := image.NewRGBA(image.Rect(0, 0, 4, 1))
+	src.SetRGBA(0, 0, greenPixel)
+	src.SetRGBA(1, 0, color.RGBA{G: 240, A: 255})
+	src.SetRGBA(2, 0, color.RGBA{G: 64, A: 128})
+	src.SetRGBA(3, 0, redPixel)
+
+	got := ColorKey{Color: color.NRGBA{G: 255, A: 255}, Tolerance: 0.1}.Apply(src)
+
+	want := []color.RGBA{clearPixel, clearPixel, {G: 64, A: 128}, redPixel}
+	for x, want := range want {
+		if pixel := got.RGBAAt(x, 0); pixel != want {
+			t.Errorf("pixel %d = %v, want %v", x, pixel, want)
+		}
+	}
+
+	got = ColorKey{Color: color.NRGBA{G: 128, A: 255}, Tolerance: 0.01}.Apply(src)
+	if pixel := got.RGBAAt(2, 0); pixel != clearPixel {
+		t.Errorf("half transparent pixel = %v, want it keyed by its straight colour", pixel)
+	}
+}
+
+func TestColorKeyFeather(t *testing.T) {
+	src := image.NewRGBA(image.Rect(0, 0, 4, 1))
+	for x, gray := range []uint8{0, 51, 102, 153} {
+		src.SetRGBA(x, 0, color.RGBA{R: gray, G: gray, B: gray, A: 255})
+	}
+
+	got := ColorKey{Color: color.NRGBA{A: 255}, Feather: 0.5}.Apply(src)
+
+	want := []color.RGBA{
+		clearPixel,
+		{R: 20, G: 20, B: 20, A: 102},
+		{R: 82, G: 82, B: 82, A: 204},
+		{R: 153, G: 153, B: 153, A: 255},
+	}
+	for x, want := range want {
+		if pixel := got.RGBAAt(x, 0); pixel != want {
+			t.Errorf("pixel %d = %v, want %v", x, pixel, want)
+		}
+	}
+}
+
+func TestColorKeyAuto(t *testing.T) {
+	src := image.NewRGBA(image.Rect(10, 10, 13, 13))
+	for y := 10; y < 13; y++ {
+		for x := 10; x < 13; x++ {
+			src.SetRGBA(x, y, bluePixel)
+		}
+	}
+	src.SetRGBA(12, 12, redPixel)
+	src.SetRGBA(11, 11, greenPixel)
+
+	if key := CornerKey(src); key != (color.NRGBA{B: 255, A: 255}) {
+		t.Fatalf("CornerKey = %v, want the blue corners", key)
+	}
+
+	got := ColorKey{Auto: true}.Apply(src)
+	if got.Bounds() != src.Bounds() {
+		t.Fatalf("bounds = %v, want %v", got.Bounds(), src.Bounds())
+	}
+	for y := 10; y < 13; y++ {
+		for x := 10; x < 13; x++ {
+			want := clearPixel
+			switch image.Pt(x, y) {
+			case image.Pt(12, 12):
+				want = redPixel
+			case image.Pt(11, 11):
+				want = greenPixel
+			}
+			if pixel := got.RGBAAt(x, y); pixel != want {
+				t.Errorf("pixel (%d, %d) = %v, want %v", x, y, pixel, want)
+			}
+		}
+	}
+}
@@ -0,0 +1,81 @@
+package images
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+type PlaybackMode int
+
+const (
+	PlaybackLoop PlaybackMode = iota
+	PlaybackOnce
+	PlaybackPingPong
+	PlaybackReverse
+)
+
+func ParsePlaybackMode(mode string) (PlaybackMode, error) {
+	switch strings.ToLower(strings.TrimSpace(mode)) {
+	case "", "loop":
+		return PlaybackLoop, nil
+	case "once":
+		return PlaybackOnce, nil
+	case "ping-pong", "pingpong":
+		return PlaybackPingPong, nil
+	case "reverse":
+		return PlaybackReverse, nil
+	}
+	return PlaybackLoop, fmt.Errorf("unknown playback mode %q", mode)
+}
+
+func (m PlaybackMode) String() string {
+	switch m {
+	case PlaybackOnce:
+		return "once"
+	case PlaybackPingPong:
+		return "ping-pong"
+	case PlaybackReverse:
+		return "reverse"
+	}
+	return "loop"
+}
+
+type DelayPolicy int
+
+const (
+	DelayClamp DelayPolicy = iota
+	DelayBrowser
+	DelayExact
+)
+
+const DefaultMinDelay = 20 * time.Millisecond
+
+func ParseDelayPolicy(policy string) (DelayPolicy, error) {
+	switch strings.ToLower(strings.TrimSpace(policy)) {
+	case "", "clamp":
+		return DelayClamp, nil
+	case "browser":
+		return DelayBrowser, nil
+	case "exact":
+		return DelayExact, nil
+	}
+	return DelayClamp, fmt.Errorf("unknown delay policy %q", policy)
+}
+
+func (p DelayPolicy) Apply(delay, minDelay time.Duration) time.Duration {
+	switch p {
+	case DelayBrowser:
+		if delay <= 10*time.Millisecond {
+			return 100 * time.Millisecond
+		}
+		return delay
+	case DelayExact:
+		return delay
+	}
+
+	if minDelay <= 0 {
+		minDelay = DefaultMinDelay
+	}
+	return max(delay, minDelay)
+}
@@ -0,0 +1,55 @@
+package images
+
+import (
+	"testing"
+	"time"
+)
+
+func ms(n int) time.Duration {
+	return time.Duration(n) * time.Millisecond
+}
+
+func TestDelayPolicyApply(t *testing.T) {
+	tests := []struct {
+		policy   DelayPolicy
+		delay    time.Duration
+		minDelay time.Duration
+		want     time.Duration
+	}{
+		{policy: DelayClamp, delay: 0, want: DefaultMinDelay},
+		{policy: DelayClamp, delay: ms(10), want: DefaultMinDelay},
+		{policy: DelayClamp, delay: ms(30), want: ms(30)},
+		{policy: DelayClamp, delay: ms(30), minDelay: ms(50), want: ms(50)},
+		{policy: DelayBrowser, delay: 0, want: ms(100)},
+		{policy: DelayBrowser, delay: ms(10), want: ms(100)},
+		{policy: DelayBrowser, delay: ms(20), want: ms(20)},
+		{policy: DelayExact, delay: 0, want: 0},
+		{policy: DelayExact, delay: ms(10), minDelay: ms(50), want: ms(10)},
+	}
+
+	for _, tt := range tests {
+		if got := tt.policy.Apply(tt.delay, tt.minDelay); got != tt.want {
+			t.Errorf("policy %d Apply(%v, %v) = %v, want %v", tt.policy, tt.delay, tt.minDelay, got, tt.want)
+		}
+	}
+}
+
+func TestParseDelayPolicy(t *testing.T) {
+	tests := map[string]DelayPolicy{
+		"":        DelayClamp,
+		"clamp":   DelayClamp,
+		"Browser": DelayBrowser,
+		" exact ": DelayExact,
+	}
+
+	for input, want := range tests {
+		got, err := ParseDelayPolicy(input)
+		if err != nil || got != want {
+			t.Errorf("ParseDelayPolicy(%q) = %v, %v, want %v", input, got, err, want)
+		}
+	}
+
+	if _, err := ParseDelayPolicy("fast"); err == nil {
+		t.Error("ParseDelayPolicy(\"fast\") returned no error")
+	}
+}
@@ -0,0 +1,183 @@
+package images
+
+import (
+	"fmt"
+	"image"
+	"math"
+	"strings"
+)
+
+type Filter struct {
+	Name    string
+	Support float64
+	Kernel  func(x float64) float64
+}
+
+var (
+	NearestNeighbor = Filter{
+		Name:    "nearest",
+		Support: 0,
+	}
+
+	Bilinear = Filter{
+		Name:    "bilinear",
+		Support: 1,
+		Kernel: func(x float64) float64 {
+			x = math.Abs(x)
+			if x < 1 {
+				return 1 - x
+			}
+			return 0
+		},
+	}
+
+	CatmullRom = Filter{
+		Name:    "catmull-rom",
+		Support: 2,
+		Kernel: func(x float64) float64 {
+			return bicubic(x, 0, 0.5)
+		},
+	}
+
+	Mitchell = Filter{
+		Name:    "mitchell",
+		Support: 2,
+		Kernel: func(x float64) float64 {
+			return bicubic(x, 1.0/3, 1.0/3)
+		},
+	}
+
+	Lanczos3 = Filter{
+		Name:    "lanczos3",
+		Support: 3,
+		Kernel: func(x float64) float64 {
+			x = math.Abs(x)
+			if x >= 3 {
+				return 0
+			}
+			return sinc(x) * sinc(x/3)
+		},
+	}
+
+	Area = Filter{
+		Name:    "area",
+		Support: 0.5,
+		Kernel: func(x float64) float64 {
+			if math.Abs(x) <= 0.5 {
+				return 1
+			}
+			return 0
+		},
+	}
+)
+
+var filters = []Filter{NearestNeighbor, Bilinear, CatmullRom, Mitchell, Lanczos3, Area}
+
+func ParseFilter(name string) (Filter, error) {
+	name = strings.ToLower(strings.TrimSpace(name))
+	switch name {
+	case "":
+		return Bilinear, nil
+	case "bicubic":
+		return CatmullRom, nil
+	case "lanczos":
+		return Lanczos3, nil
+	}
+
+	for _, filter := range filters {
+		if filter.Name == name {
+			return filter, nil
+		}
+	}
+
+	return Filter{}, fmt.Errorf("unknown resize filter %q", name)
+}
+
+func ResizeWith(i image.Image, newWidth, newHeight int, filter Filter) image.Image {
+	return resizeWith(i, max(newWidth, 0), max(newHeight, 0), filter)
+}
+
+type resampleTap struct {
+	index  int
+	weight float64
+}
+
+func resampleWeights(srcSize, dstSize int, filter Filter) [][]resampleTap {
+	scale := float64(srcSize) / float64(dstSize)
+	weights := make([][]resampleTap, dstSize)
+
+	if filter.Kernel == nil {
+		for i := range weights {
+			index := min(int((float64(i)+0.5)*scale), srcSize-1)
+			weights[i] = []resampleTap{{index: index, weight: 1}}
+		}
+		return weights
+	}
+
+	filterScale := max(scale, 1)
+	support := filter.Support * filterScale
+
+	for i := range weights {
+		center := (float64(i)+0.5)*scale - 0.5
+		start := int(math.Ceil(center - support))
+		end := int(math.Floor(center + support))
+
+		var taps []resampleTap
+		var values []float64
+		var total float64
+
+		for j := start; j <= end; j++ {
+			weight := filter.Kernel((float64(j) - center) / filterScale)
+			if weight == 0 {
+				continue
+			}
+
+			index := min(max(j, 0), srcSize-1)
+			taps = append(taps, resampleTap{index: index})
+			values = append(values, weight)
+			total += weight
+		}
+
+		if len(taps) == 0 || total == 0 {
+			index := min(max(int(math.Round(center)), 0), srcSize-1)
+			weights[i] = []resampleTap{{index: index, weight: 1}}
+			continue
+		}
+
+		for j := range taps {
+			taps[j].weight = values[j] / total
+		}
+		weights[i] = taps
+	}
+
+	return weights
+}
+
+func bicubic(x, b, c float64) float64 {
+	x = math.Abs(x)
+	switch {
+	case x < 1:
+		return ((12-9*b-6*c)*x*x*x + (-18+12*b+6*c)*x*x + (6 - 2*b)) / 6
+	case x < 2:
+		return ((-b-6*c)*x*x*x + (6*b+30*c)*x*x + (-12*b-48*c)*x + (8*b + 24*c)) / 6
+	}
+	return 0
+}
+
+func sinc(x float64) float64 {
+	if x == 0 {
+		return 1
+	}
+	x *= math.Pi
+	return math.Sin(x) / x
+}
+
+func clampUint8(v int32) uint8 {
+	if v <= 0 {
+		return 0
+	}
+	if v >= 255 {
+		return 255
+	}
+	return uint8(v)
+}
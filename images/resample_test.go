@@ -0,0 +1,111 @@
+package images
+
+import (
+	"image"
+	"image/color"
+	"testing"
+)
+
+func TestResizeNearestKeepsEdges(t *testing.T) {
+	palette := []color.RGBA{redPixel, bluePixel, greenPixel, {R: 255, G: 255, B: 255, A: 255}}
+	src := image.NewRGBA(image.Rect(0, 0, 2, 2))
+	for i, c := range palette {
+		src.SetRGBA(i%2, i/2, c)
+	}
+
+	up := ResizeWith(src, 8, 8, NearestNeighbor).(*image.RGBA)
+	for y := range 8 {
+		for x := range 8 {
+			if got, want := up.RGBAAt(x, y), src.RGBAAt(x/4, y/4); got != want {
+				t.Fatalf("upscaled pixel (%d, %d) = %v, want %v", x, y, got, want)
+			}
+		}
+	}
+
+	down := ResizeWith(up, 2, 2, NearestNeighbor).(*image.RGBA)
+	if !equalRGBA(down, src) {
+		t.Fatal("downscaling the upscaled image did not restore the original pixels")
+	}
+}
+
+func TestResizeAreaMeans(t *testing.T) {
+	src := image.NewRGBA(image.Rect(0, 0, 4, 2))
+	for x, gray := range []uint8{0, 100, 200, 40} {
+		for y := range 2 {
+			src.SetRGBA(x, y, color.RGBA{R: gray, G: gray, B: gray, A: 255})
+		}
+	}
+
+	tests := []struct {
+		width, height int
+		want          []uint8
+	}{
+		{width: 2, height: 1, want: []uint8{50, 120}},
+		{width: 1, height: 1, want: []uint8{85}},
+		{width: 4, height: 1, want: []uint8{0, 100, 200, 40}},
+	}
+
+	for _, tt := range tests {
+		got := ResizeWith(src, tt.width, tt.height, Area).(*image.RGBA)
+		for x, want := range tt.want {
+			if pixel := got.RGBAAt(x, 0); pixel != (color.RGBA{R: want, G: want, B: want, A: 255}) {
+				t.Errorf("%dx%d: pixel %d = %v, want gray %d", tt.width, tt.height, x, pixel, want)
+			}
+		}
+	}
+}
+
+func TestResizeFlatColorHasNoFringe(t *testing.T) {
+	flat := color.RGBA{R: 90, G: 160, B: 30, A: 255}
+	src := image.NewRGBA(image.Rect(0, 0, 7, 5))
+	for y := range 5 {
+		for x := range 7 {
+			src.SetRGBA(x, y, flat)
+		}
+	}
+
+	for _, filter := range filters {
+		for _, size := range []image.Point{{19, 13}, {3, 2}, {7, 1}} {
+			got := ResizeWith(src, size.X, size.Y, filter).(*image.RGBA)
+			for y := range size.Y {
+				for x := range size.X {
+					if pixel := got.RGBAAt(x, y); pixel != flat {
+						t.Fatalf("%s to %v: pixel (%d, %d) = %v, want %v", filter.Name, size, x, y, pixel, flat)
+					}
+				}
+			}
+		}
+	}
+}
+
+func TestResizeTransparentPixelsDoNotBleed(t *testing.T) {
+	src := image.NewNRGBA(image.Rect(0, 0, 4, 4))
+	for y := range 4 {
+		for x := range 4 {
+			c := color.NRGBA{G: 255}
+			if x < 2 {
+				c = color.NRGBA{R: 255, A: 255}
+			}
+			src.SetNRGBA(x, y, c)
+		}
+	}
+
+	for _, filter := range filters {
+		for _, size := range []image.Point{{1, 1}, {3, 3}, {9, 9}} {
+			got := ResizeWith(src, size.X, size.Y, filter).(*image.RGBA)
+			for y := range size.Y {
+				for x := range size.X {
+					pixel := got.RGBAAt(x, y)
+					if pixel.G != 0 || pixel.B != 0 || pixel.R > pixel.A {
+						t.Fatalf("%s to %v: pixel (%d, %d) = %v, want red premultiplied by its alpha", filter.Name, size, x, y, pixel)
+					}
+				}
+			}
+		}
+	}
+
+	got := ResizeWith(src, 1, 1, Area).(*image.RGBA)
+	if pixel := got.RGBAAt(0, 0); pixel != (color.RGBA{R: 128, A: 128}) {
+		t.Fatalf("area mean = %v, want half transparent red", pixel)
+	}
+}
@@ -0,0 +1,338 @@
+package images
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+	"math"
+	"runtime"
+	"sync"
+)
+
+const (
+	minBandRows = 16
+
+	weightBits       = 14
+	intermediateBits = 7
+	outputShift      = 2*weightBits - intermediateBits
+)
+
+type Resizer struct {
+	mu         sync.Mutex
+	filter     Filter
+	scratch    resizeScratch
+	srcSize    image.Point
+	dstSize    image.Point
+	horizontal kernel
+	vertical   kernel
+	dst        *image.RGBA
+}
+
+type kernel struct {
+	start  []int
+	index  []int
+	weight []int32
+}
+
+type resizeScratch struct {
+	temp    []int32
+	rows    [][]uint8
+	accum   [][]int32
+	palette []uint8
+	rgba    *image.RGBA
+}
+
+var scratchPool = sync.Pool{
+	New: func() any {
+		return &resizeScratch{}
+	},
+}
+
+func NewResizer(filter Filter) *Resizer {
+	return &Resizer{filter: filter}
+}
+
+func (r *Resizer) Filter() Filter {
+	return r.filter
+}
+
+func (r *Resizer) Resize(i image.Image, newWidth, newHeight int) *image.RGBA {
+	dst := image.NewRGBA(image.Rect(0, 0, max(newWidth, 0), max(newHeight, 0)))
+	r.ResizeInto(dst, i)
+	return dst
+}
+
+func (r *Resizer) ResizeInto(dst *image.RGBA, i image.Image) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.resizeInto(dst, i)
+}
+
+func (r *Resizer) ResizeThen(i image.Image, newWidth, newHeight int, then func(*image.RGBA) *image.RGBA) *image.RGBA {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	size := image.Rect(0, 0, max(newWidth, 0), max(newHeight, 0))
+	if r.dst == nil || r.dst.Bounds() != size {
+		r.dst = image.NewRGBA(size)
+	}
+	r.resizeInto(r.dst, i)
+
+	out := then(r.dst)
+	if sharesPixels(out, r.dst) {
+		clone := image.NewRGBA(out.Bounds())
+		copy(clone.Pix, out.Pix)
+		out = clone
+	}
+	return out
+}
+
+func sharesPixels(a, b *image.RGBA) bool {
+	if cap(a.Pix) == 0 || cap(b.Pix) == 0 {
+		return false
+	}
+	return &a.Pix[:cap(a.Pix)][cap(a.Pix)-1] == &b.Pix[:cap(b.Pix)][cap(b.Pix)-1]
+}
+
+func (r *Resizer) resizeInto(dst *image.RGBA, i image.Image) {
+	bounds := i.Bounds()
+	srcSize := image.Pt(bounds.Dx(), bounds.Dy())
+	dstSize := image.Pt(dst.Bounds().Dx(), dst.Bounds().Dy())
+
+	if srcSize != r.srcSize || dstSize != r.dstSize || r.horizontal.start == nil {
+		r.srcSize, r.dstSize = srcSize, dstSize
+		r.horizontal = newKernel(resampleWeights(srcSize.X, dstSize.X, r.filter))
+		r.vertical = newKernel(resampleWeights(srcSize.Y, dstSize.Y, r.filter))
+	}
+
+	resample(dst, i, r.horizontal, r.vertical, &r.scratch)
+}
+
+func resizeWith(i image.Image, newWidth, newHeight int, filter Filter) *image.RGBA {
+	dst := image.NewRGBA(image.Rect(0, 0, newWidth, newHeight))
+
+	bounds := i.Bounds()
+	horizontal := newKernel(resampleWeights(bounds.Dx(), newWidth, filter))
+	vertical := newKernel(resampleWeights(bounds.Dy(), newHeight, filter))
+
+	scratch := scratchPool.Get().(*resizeScratch)
+	resample(dst, i, horizontal, vertical, scratch)
+	scratch.rgba = nil
+	scratchPool.Put(scratch)
+
+	return dst
+}
+
+func newKernel(weights [][]resampleTap) kernel {
+	k := kernel{start: make([]int, len(weights)+1)}
+
+	for i, taps := range weights {
+		k.start[i] = len(k.index)
+
+		var total int32
+		largest := len(k.weight)
+		for _, tap := range taps {
+			weight := int32(math.Round(tap.weight * (1 << weightBits)))
+			if len(k.weight) > largest && weight > k.weight[largest] {
+				largest = len(k.weight)
+			}
+			k.index = append(k.index, tap.index)
+			k.weight = append(k.weight, weight)
+			total += weight
+		}
+
+		if len(taps) > 0 {
+			k.weight[largest] += 1<<weightBits - total
+		}
+	}
+	k.start[len(weights)] = len(k.index)
+
+	return k
+}
+
+func (k kernel) size() int {
+	return len(k.start) - 1
+}
+
+func resample(dst *image.RGBA, i image.Image, horizontal, vertical kernel, scratch *resizeScratch) {
+	bounds := i.Bounds()
+	width, height := bounds.Dx(), bounds.Dy()
+	newWidth, newHeight := horizontal.size(), vertical.size()
+
+	if newWidth <= 0 || newHeight <= 0 {
+		return
+	}
+	if width == 0 || height == 0 {
+		clear(dst.Pix)
+		return
+	}
+
+	readRow := scratch.rowReader(i)
+
+	tempSize := newWidth * height * 4
+	if cap(scratch.temp) < tempSize {
+		scratch.temp = make([]int32, tempSize)
+	}
+	temp := scratch.temp[:tempSize]
+
+	bands := bandCount(max(height, newHeight))
+	for len(scratch.rows) < bands {
+		scratch.rows = append(scratch.rows, nil)
+		scratch.accum = append(scratch.accum, nil)
+	}
+	for band := range bands {
+		if cap(scratch.rows[band]) < width*4 {
+			scratch.rows[band] = make([]uint8, width*4)
+		}
+		if cap(scratch.accum[band]) < newWidth*4 {
+			scratch.accum[band] = make([]int32, newWidth*4)
+		}
+	}
+
+	parallel(height, bands, func(band, from, to int) {
+		buf := scratch.rows[band][:width*4]
+		for y := from; y < to; y++ {
+			row := readRow(y, buf)
+			out := temp[y*newWidth*4 : (y+1)*newWidth*4]
+
+			for x := 0; x < newWidth; x++ {
+				var r, g, b, a int32
+				start, end := horizontal.start[x], horizontal.start[x+1]
+				for t, index := range horizontal.index[start:end] {
+					weight := horizontal.weight[start+t]
+					p := row[index*4 : index*4+4 : index*4+4]
+					r += int32(p[0]) * weight
+					g += int32(p[1]) * weight
+					b += int32(p[2]) * weight
+					a += int32(p[3]) * weight
+				}
+
+				const round = 1 << (weightBits - intermediateBits - 1)
+				o := out[x*4 : x*4+4 : x*4+4]
+				o[0] = (r + round) >> (weightBits - intermediateBits)
+				o[1] = (g + round) >> (weightBits - intermediateBits)
+				o[2] = (b + round) >> (weightBits - intermediateBits)
+				o[3] = (a + round) >> (weightBits - intermediateBits)
+			}
+		}
+	})
+
+	dstMin := dst.Bounds().Min
+	parallel(newHeight, bands, func(band, from, to int) {
+		accum := scratch.accum[band][:newWidth*4]
+		for y := from; y < to; y++ {
+			clear(accum)
+
+			start, end := vertical.start[y], vertical.start[y+1]
+			for t, index := range vertical.index[start:end] {
+				weight := vertical.weight[start+t]
+				row := temp[index*newWidth*4 : (index+1)*newWidth*4]
+				for x, value := range row {
+					accum[x] += value * weight
+				}
+			}
+
+			const round = 1 << (outputShift - 1)
+			offset := dst.PixOffset(dstMin.X, dstMin.Y+y)
+			out := dst.Pix[offset : offset+newWidth*4]
+			for x := 0; x < len(out); x += 4 {
+				alpha := clampUint8((accum[x+3] + round) >> outputShift)
+				out[x+0] = min(clampUint8((accum[x+0]+round)>>outputShift), alpha)
+				out[x+1] = min(clampUint8((accum[x+1]+round)>>outputShift), alpha)
+				out[x+2] = min(clampUint8((accum[x+2]+round)>>outputShift), alpha)
+				out[x+3] = alpha
+			}
+		}
+	})
+}
+
+func (s *resizeScratch) rowReader(i image.Image) func(y int, buf []uint8) []uint8 {
+	bounds := i.Bounds()
+	width := bounds.Dx()
+
+	switch src := i.(type) {
+	case *image.RGBA:
+		return func(y int, buf []uint8) []uint8 {
+			offset := src.PixOffset(bounds.Min.X, bounds.Min.Y+y)
+			return src.Pix[offset : offset+width*4]
+		}
+
+	case *image.NRGBA:
+		return func(y int, buf []uint8) []uint8 {
+			offset := src.PixOffset(bounds.Min.X, bounds.Min.Y+y)
+			row := src.Pix[offset : offset+width*4]
+			for x := 0; x < width*4; x += 4 {
+				a := uint32(row[x+3])
+				buf[x+0] = uint8(uint32(row[x+0]) * a / 255)
+				buf[x+1] = uint8(uint32(row[x+1]) * a / 255)
+				buf[x+2] = uint8(uint32(row[x+2]) * a / 255)
+				buf[x+3] = uint8(a)
+			}
+			return buf
+		}
+
+	case *image.Paletted:
+		if cap(s.palette) < 256*4 {
+			s.palette = make([]uint8, 256*4)
+		}
+		palette := s.palette[:256*4]
+		clear(palette)
+		for index, c := range src.Palette {
+			rgba := color.RGBAModel.Convert(c).(color.RGBA)
+			palette[index*4+0] = rgba.R
+			palette[index*4+1] = rgba.G
+			palette[index*4+2] = rgba.B
+			palette[index*4+3] = rgba.A
+		}
+
+		return func(y int, buf []uint8) []uint8 {
+			offset := src.PixOffset(bounds.Min.X, bounds.Min.Y+y)
+			for x, index := range src.Pix[offset : offset+width] {
+				copy(buf[x*4:x*4+4], palette[int(index)*4:int(index)*4+4])
+			}
+			return buf
+		}
+	}
+
+	size := image.Rect(0, 0, width, bounds.Dy())
+	if s.rgba == nil || s.rgba.Bounds() != size {
+		s.rgba = image.NewRGBA(size)
+	}
+	draw.Draw(s.rgba, size, i, bounds.Min, draw.Src)
+
+	rgba := s.rgba
+	return func(y int, buf []uint8) []uint8 {
+		return rgba.Pix[y*rgba.Stride : y*rgba.Stride+width*4]
+	}
+}
+
+func bandCount(rows int) int {
+	return max(min(runtime.GOMAXPROCS(0), rows/minBandRows), 1)
+}
+
+func parallel(rows, bands int, work func(band, from, to int)) {
+	if bands <= 1 {
+		work(0, 0, rows)
+		return
+	}
+
+	var wg sync.WaitGroup
+	size := (rows + bands - 1) / bands
+
+	for band := range bands {
+		from := band * size
+		to := min(from+size, rows)
+		if from >= to {
+			break
+		}
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			work(band, from, to)
+		}()
+	}
+
+	wg.Wait()
+}
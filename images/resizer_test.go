@@ -0,0 +1,169 @@
+package images
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"testing"
+)
+
+var mascotSizes = []struct {
+	name     string
+	src, dst image.Point
+}{
+	{name: "512to256", src: image.Pt(512, 512), dst: image.Pt(256, 256)},
+	{name: "320x480to160x240", src: image.Pt(320, 480), dst: image.Pt(160, 240)},
+	{name: "128to384", src: image.Pt(128, 128), dst: image.Pt(384, 384)},
+}
+
+func testSources(size image.Point) map[string]image.Image {
+	rect := image.Rect(0, 0, size.X, size.Y)
+	rgba := image.NewRGBA(rect)
+	nrgba := image.NewNRGBA(rect)
+	paletted := image.NewPaletted(rect, color.Palette{color.Transparent, color.White, color.RGBA{R: 200, A: 255}, color.RGBA{B: 120, A: 128}})
+
+	for y := range size.Y {
+		for x := range size.X {
+			c := color.NRGBA{R: uint8(x), G: uint8(y), B: uint8(x ^ y), A: uint8(x + y)}
+			nrgba.SetNRGBA(x, y, c)
+			rgba.Set(x, y, c)
+			paletted.SetColorIndex(x, y, uint8((x/7+y/5)%4))
+		}
+	}
+
+	return map[string]image.Image{"rgba": rgba, "nrgba": nrgba, "paletted": paletted}
+}
+
+func perPixelResize(i image.Image, newWidth, newHeight int) image.Image {
+	bounds := i.Bounds()
+	width := bounds.Dx()
+	height := bounds.Dy()
+
+	newImg := image.NewRGBA(image.Rect(0, 0, newWidth, newHeight))
+
+	for y := 0; y < newHeight; y++ {
+		for x := 0; x < newWidth; x++ {
+			srcX := float64(x) * float64(width) / float64(newWidth)
+			srcY := float64(y) * float64(height) / float64(newHeight)
+
+			x1, y1 := int(srcX), int(srcY)
+			x2, y2 := min(x1+1, width-1), min(y1+1, height-1)
+
+			c1 := color.RGBAModel.Convert(i.At(x1, y1)).(color.RGBA)
+			c2 := color.RGBAModel.Convert(i.At(x2, y1)).(color.RGBA)
+			c3 := color.RGBAModel.Convert(i.At(x1, y2)).(color.RGBA)
+			c4 := color.RGBAModel.Convert(i.At(x2, y2)).(color.RGBA)
+
+			wx, wy := srcX-float64(x1), srcY-float64(y1)
+			mix := func(a, b, c, d uint8) uint8 {
+				return uint8((1-wx)*(1-wy)*float64(a) + wx*(1-wy)*float64(b) + (1-wx)*wy*float64(c) + wx*wy*float64(d))
+			}
+
+			newImg.Set(x, y, color.RGBA{
+				R: mix(c1.R, c2.R, c3.R, c4.R),
+				G: mix(c1.G, c2.G, c3.G, c4.G),
+				B: mix(c1.B, c2.B, c3.B, c4.B),
+				A: mix(c1.A, c2.A, c3.A, c4.A),
+			})
+		}
+	}
+
+	return newImg
+}
+
+func BenchmarkResizePerPixel(b *testing.B) {
+	for _, size := range mascotSizes {
+		for kind, src := range testSources(size.src) {
+			b.Run(fmt.Sprintf("%s/%s", size.name, kind), func(b *testing.B) {
+				b.ReportAllocs()
+				for b.Loop() {
+					perPixelResize(src, size.dst.X, size.dst.Y)
+				}
+			})
+		}
+	}
+}
+
+func BenchmarkResizer(b *testing.B) {
+	for _, size := range mascotSizes {
+		for kind, src := range testSources(size.src) {
+			b.Run(fmt.Sprintf("%s/%s", size.name, kind), func(b *testing.B) {
+				resizer := NewResizer(Bilinear)
+				b.ReportAllocs()
+				for b.Loop() {
+					resizer.Resize(src, size.dst.X, size.dst.Y)
+				}
+			})
+		}
+	}
+}
+
+func BenchmarkResizerInto(b *testing.B) {
+	for _, size := range mascotSizes {
+		for kind, src := range testSources(size.src) {
+			b.Run(fmt.Sprintf("%s/%s", size.name, kind), func(b *testing.B) {
+				resizer := NewResizer(Bilinear)
+				dst := image.NewRGBA(image.Rect(0, 0, size.dst.X, size.dst.Y))
+				b.ReportAllocs()
+				for b.Loop() {
+					resizer.ResizeInto(dst, src)
+				}
+			})
+		}
+	}
+}
+
+func TestResizerSourcesAgree(t *testing.T) {
+	src := testSources(image.Pt(64, 48))
+	resizer := NewResizer(CatmullRom)
+
+	want := resizer.Resize(src["rgba"], 40, 30)
+	for _, kind := range []string{"nrgba"} {
+		got := resizer.Resize(src[kind], 40, 30)
+		for i := range got.Pix {
+			if diff := int(got.Pix[i]) - int(want.Pix[i]); diff < -1 || diff > 1 {
+				t.Fatalf("%s source differs from rgba at byte %d: %d vs %d", kind, i, got.Pix[i], want.Pix[i])
+			}
+		}
+	}
+
+	paletted := src["paletted"]
+	generic := image.NewRGBA64(paletted.Bounds())
+	for y := range 48 {
+		for x := range 64 {
+			generic.Set(x, y, paletted.At(x, y))
+		}
+	}
+	if !equalRGBA(resizer.Resize(paletted, 40, 30), resizer.Resize(generic, 40, 30)) {
+		t.Fatal("paletted fast path differs from the generic path")
+	}
+}
+
+func TestResizerThenReusesBuffer(t *testing.T) {
+	src := testSources(image.Pt(32, 32))["rgba"]
+	resizer := NewResizer(Bilinear)
+
+	var buffers []*image.RGBA
+	crop := func(resized *image.RGBA) *image.RGBA {
+		buffers = append(buffers, resized)
+		return CropCenter(resized, 8, 8)
+	}
+
+	first := resizer.ResizeThen(src, 16, 16, crop)
+	second := resizer.ResizeThen(src, 16, 16, crop)
+
+	if buffers[0] != buffers[1] {
+		t.Fatal("ResizeThen allocated a new destination for the same size")
+	}
+	if !equalRGBA(first, second) || first.Bounds() != image.Rect(0, 0, 8, 8) {
+		t.Fatal("ResizeThen results differ between calls")
+	}
+
+	kept := resizer.ResizeThen(src, 16, 16, func(resized *image.RGBA) *image.RGBA { return resized })
+	if sharesPixels(kept, buffers[0]) {
+		t.Fatal("ResizeThen returned its scratch buffer")
+	}
+	if !equalRGBA(kept, resizer.Resize(src, 16, 16)) {
+		t.Fatal("copied result differs from Resize")
+	}
+}
@@ -0,0 +1,120 @@
+package images
+
+import (
+	"fmt"
+	"image"
+	"math"
+	"strings"
+)
+
+type SizeMode int
+
+const (
+	SizeStretch SizeMode = iota
+	SizeContain
+	SizeFit
+	SizeCover
+	SizeWidth
+	SizeHeight
+	SizeMax
+)
+
+func ParseSizeMode(mode string) (SizeMode, error) {
+	switch strings.ToLower(strings.TrimSpace(mode)) {
+	case "", "stretch", "fill":
+		return SizeStretch, nil
+	case "contain":
+		return SizeContain, nil
+	case "fit":
+		return SizeFit, nil
+	case "cover":
+		return SizeCover, nil
+	case "width", "width-only":
+		return SizeWidth, nil
+	case "height", "height-only":
+		return SizeHeight, nil
+	case "max", "max-dimension":
+		return SizeMax, nil
+	}
+	return SizeStretch, fmt.Errorf("unknown resize mode %q", mode)
+}
+
+func (m SizeMode) String() string {
+	switch m {
+	case SizeContain:
+		return "contain"
+	case SizeFit:
+		return "fit"
+	case SizeCover:
+		return "cover"
+	case SizeWidth:
+		return "width"
+	case SizeHeight:
+		return "height"
+	case SizeMax:
+		return "max"
+	}
+	return "stretch"
+}
+
+func (m SizeMode) UsesWidth() bool {
+	return m != SizeHeight
+}
+
+func (m SizeMode) UsesHeight() bool {
+	return m != SizeWidth && m != SizeMax
+}
+
+func ResolveSize(src image.Point, width, height int, mode SizeMode) image.Point {
+	if src.X <= 0 || src.Y <= 0 {
+		return image.Point{}
+	}
+
+	sx := float64(width) / float64(src.X)
+	sy := float64(height) / float64(src.Y)
+
+	switch mode {
+	case SizeContain:
+		return scaleSize(src, math.Min(sx, sy))
+	case SizeFit:
+		return scaleSize(src, math.Min(math.Min(sx, sy), 1))
+	case SizeCover:
+		return scaleSize(src, math.Max(sx, sy))
+	case SizeWidth:
+		return scaleSize(src, sx)
+	case SizeHeight:
+		return scaleSize(src, sy)
+	case SizeMax:
+		return scaleSize(src, float64(width)/float64(max(src.X, src.Y)))
+	}
+
+	return image.Pt(max(width, 1), max(height, 1))
+}
+
+func scaleSize(src image.Point, scale float64) image.Point {
+	return image.Pt(
+		max(int(math.Round(float64(src.X)*scale)), 1),
+		max(int(math.Round(float64(src.Y)*scale)), 1),
+	)
+}
+
+func ScaleOrigin(bounds image.Rectangle, size image.Point) image.Point {
+	if bounds.Dx() <= 0 || bounds.Dy() <= 0 {
+		return image.Point{}
+	}
+
+	return image.Pt(
+		int(math.Round(float64(bounds.Min.X)*float64(size.X)/float64(bounds.Dx()))),
+		int(math.Round(float64(bounds.Min.Y)*float64(size.Y)/float64(bounds.Dy()))),
+	)
+}
+
+func CropCenter(i *image.RGBA, width, height int) *image.RGBA {
+	bounds := i.Bounds()
+	width, height = min(max(width, 1), bounds.Dx()), min(max(height, 1), bounds.Dy())
+
+	x := bounds.Min.X + (bounds.Dx()-width)/2
+	y := bounds.Min.Y + (bounds.Dy()-height)/2
+
+	return copyRegion(i, image.Rect(x, y, x+width, y+height))
+}
@@ -0,0 +1,72 @@
+package images
+
+import (
+	"image"
+	"testing"
+)
+
+func TestResolveSize(t *testing.T) {
+	landscape := image.Pt(200, 100)
+	portrait := image.Pt(100, 200)
+
+	tests := []struct {
+		name          string
+		mode          SizeMode
+		src           image.Point
+		width, height int
+		want          image.Point
+	}{
+		{name: "stretch", mode: SizeStretch, src: landscape, width: 50, height: 80, want: image.Pt(50, 80)},
+		{name: "stretch/zero", mode: SizeStretch, src: landscape, width: 0, height: 0, want: image.Pt(1, 1)},
+		{name: "contain/wide box", mode: SizeContain, src: landscape, width: 400, height: 100, want: image.Pt(200, 100)},
+		{name: "contain/square box", mode: SizeContain, src: landscape, width: 100, height: 100, want: image.Pt(100, 50)},
+		{name: "contain/upscale", mode: SizeContain, src: portrait, width: 400, height: 400, want: image.Pt(200, 400)},
+		{name: "fit/downscale", mode: SizeFit, src: landscape, width: 100, height: 100, want: image.Pt(100, 50)},
+		{name: "fit/never upscale", mode: SizeFit, src: landscape, width: 400, height: 400, want: image.Pt(200, 100)},
+		{name: "cover/square box", mode: SizeCover, src: landscape, width: 100, height: 100, want: image.Pt(200, 100)},
+		{name: "cover/wide box", mode: SizeCover, src: landscape, width: 400, height: 100, want: image.Pt(400, 200)},
+		{name: "cover/portrait", mode: SizeCover, src: portrait, width: 50, height: 50, want: image.Pt(50, 100)},
+		{name: "width", mode: SizeWidth, src: landscape, width: 100, height: 999, want: image.Pt(100, 50)},
+		{name: "width/tiny", mode: SizeWidth, src: landscape, width: 2, height: 0, want: image.Pt(2, 1)},
+		{name: "height", mode: SizeHeight, src: landscape, width: 999, height: 50, want: image.Pt(100, 50)},
+		{name: "height/upscale", mode: SizeHeight, src: portrait, width: 0, height: 300, want: image.Pt(150, 300)},
+		{name: "max/landscape", mode: SizeMax, src: landscape, width: 100, height: 999, want: image.Pt(100, 50)},
+		{name: "max/portrait", mode: SizeMax, src: portrait, width: 100, height: 999, want: image.Pt(50, 100)},
+		{name: "empty source", mode: SizeContain, src: image.Pt(0, 10), width: 100, height: 100, want: image.Point{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ResolveSize(tt.src, tt.width, tt.height, tt.mode); got != tt.want {
+				t.Fatalf("ResolveSize(%v, %d, %d, %s) = %v, want %v", tt.src, tt.width, tt.height, tt.mode, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseSizeMode(t *testing.T) {
+	tests := map[string]SizeMode{
+		"":              SizeStretch,
+		"fill":          SizeStretch,
+		"contain":       SizeContain,
+		"Fit":           SizeFit,
+		"cover":         SizeCover,
+		"width-only":    SizeWidth,
+		"height":        SizeHeight,
+		"max-dimension": SizeMax,
+	}
+
+	for input, want := range tests {
+		got, err := ParseSizeMode(input)
+		if err != nil || got != want {
+			t.Errorf("ParseSizeMode(%q) = %v, %v, want %v", input, got, err, want)
+		}
+		if again, _ := ParseSizeMode(got.String()); again != got {
+			t.Errorf("%v does not round-trip through String", got)
+		}
+	}
+
+	if _, err := ParseSizeMode("zoom"); err == nil {
+		t.Error("ParseSizeMode(\"zoom\") returned no error")
+	}
+}
@@ -0,0 +1,199 @@
+package images
+
+import (
+	"container/list"
+	"fmt"
+	"image"
+	"sync"
+)
+
+const DefaultCheckpointInterval = 16
+
+type FrameSource interface {
+	FrameCount() int
+	Canvas() image.Rectangle
+	FrameDelay(i int) int
+	PlayCount() int
+	Frame(i int) (*image.RGBA, error)
+}
+
+func (a *Animation) FrameCount() int {
+	return len(a.Frames)
+}
+
+func (a *Animation) Canvas() image.Rectangle {
+	if len(a.Frames) == 0 {
+		return image.Rectangle{}
+	}
+	return a.Frames[0].Bounds()
+}
+
+func (a *Animation) FrameDelay(i int) int {
+	return a.Delays[i]
+}
+
+func (a *Animation) PlayCount() int {
+	return a.Plays
+}
+
+func (a *Animation) Frame(i int) (*image.RGBA, error) {
+	if i < 0 || i >= len(a.Frames) {
+		return nil, fmt.Errorf("frame %d out of range", i)
+	}
+	return a.Frames[i], nil
+}
+
+type StreamingGIF struct {
+	mu          sync.Mutex
+	index       *gifIndex
+	canvas      image.Rectangle
+	interval    int
+	checkpoints []*image.RGBA
+	state       *image.RGBA
+	next        int
+	capacity    int
+	entries     map[int]*list.Element
+	order       *list.List
+}
+
+type streamedFrame struct {
+	index int
+	image *image.RGBA
+}
+
+func LoadGIF(data []byte, memoryLimit int64) (FrameSource, error) {
+	if memoryLimit > 0 {
+		index, err := indexGIF(data)
+		if err != nil {
+			return nil, err
+		}
+		if index.memory() > memoryLimit {
+			return newStreamingGIF(index, int64(len(data)), memoryLimit, DefaultCheckpointInterval)
+		}
+	}
+
+	animation, err := DecodeGIF(data)
+	if err != nil {
+		return nil, err
+	}
+	return animation, nil
+}
+
+func NewStreamingGIF(data []byte, memoryLimit int64, interval int) (*StreamingGIF, error) {
+	index, err := indexGIF(data)
+	if err != nil {
+		return nil, err
+	}
+	return newStreamingGIF(index, int64(len(data)), memoryLimit, interval)
+}
+
+func newStreamingGIF(index *gifIndex, encodedSize, memoryLimit int64, interval int) (*StreamingGIF, error) {
+	if interval <= 0 {
+		interval = DefaultCheckpointInterval
+	}
+
+	frameSize := max(int64(index.canvas.Dx())*int64(index.canvas.Dy())*4, 1)
+	checkpoints := int64((len(index.frames) + interval - 1) / interval)
+
+	s := &StreamingGIF{
+		index:    index,
+		canvas:   index.canvas,
+		interval: interval,
+		capacity: int(max((memoryLimit-encodedSize)/frameSize-checkpoints, 2)),
+		entries:  make(map[int]*list.Element),
+		order:    list.New(),
+	}
+
+	if err := s.buildCheckpoints(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+func (s *StreamingGIF) buildCheckpoints() error {
+	state := image.NewRGBA(s.canvas)
+
+	for i, frame := range s.index.frames {
+		if i%s.interval == 0 {
+			checkpoint := image.NewRGBA(s.canvas)
+			copy(checkpoint.Pix, state.Pix)
+			s.checkpoints = append(s.checkpoints, checkpoint)
+		}
+
+		paletted, err := s.index.decode(i)
+		if err != nil {
+			return err
+		}
+		compositeGIFFrame(state, paletted, frame.disposal, nil)
+	}
+
+	s.state = nil
+	s.next = 0
+	return nil
+}
+
+func (s *StreamingGIF) FrameCount() int {
+	return len(s.index.frames)
+}
+
+func (s *StreamingGIF) Canvas() image.Rectangle {
+	return s.canvas
+}
+
+func (s *StreamingGIF) FrameDelay(i int) int {
+	return s.index.frames[i].delay
+}
+
+func (s *StreamingGIF) PlayCount() int {
+	return gifPlays(s.index.loopCount)
+}
+
+func (s *StreamingGIF) Frame(i int) (*image.RGBA, error) {
+	if i < 0 || i >= len(s.index.frames) {
+		return nil, fmt.Errorf("frame %d out of range", i)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if element, ok := s.entries[i]; ok {
+		s.order.MoveToFront(element)
+		return element.Value.(*streamedFrame).image, nil
+	}
+
+	if s.state == nil || i < s.next || i-s.next >= s.interval {
+		checkpoint := i / s.interval
+		s.state = image.NewRGBA(s.canvas)
+		copy(s.state.Pix, s.checkpoints[checkpoint].Pix)
+		s.next = checkpoint * s.interval
+	}
+
+	frame := image.NewRGBA(s.canvas)
+	for s.next <= i {
+		paletted, err := s.index.decode(s.next)
+		if err != nil {
+			s.state = nil
+			return nil, err
+		}
+
+		var out *image.RGBA
+		if s.next == i {
+			out = frame
+		}
+		compositeGIFFrame(s.state, paletted, s.index.frames[s.next].disposal, out)
+		s.next++
+	}
+
+	s.remember(i, frame)
+	return frame, nil
+}
+
+func (s *StreamingGIF) remember(i int, frame *image.RGBA) {
+	s.entries[i] = s.order.PushFront(&streamedFrame{index: i, image: frame})
+
+	for s.order.Len() > s.capacity {
+		oldest := s.order.Back()
+		s.order.Remove(oldest)
+		delete(s.entries, oldest.Value.(*streamedFrame).index)
+	}
+}
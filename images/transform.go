@@ -0,0 +1,288 @@
+package images
+
+import (
+	"fmt"
+	"image"
+	"image/draw"
+	"math"
+	"strings"
+)
+
+type Transform interface {
+	Apply(i *image.RGBA) *image.RGBA
+	String() string
+}
+
+type Pipeline []Transform
+
+func (p Pipeline) Apply(i image.Image) *image.RGBA {
+	rgba := toRGBA(i)
+	for _, transform := range p {
+		rgba = transform.Apply(rgba)
+	}
+	return rgba
+}
+
+func (p Pipeline) String() string {
+	names := make([]string, len(p))
+	for i, transform := range p {
+		names[i] = transform.String()
+	}
+	return strings.Join(names, ",")
+}
+
+type Crop struct {
+	Rect image.Rectangle
+}
+
+func (c Crop) Apply(i *image.RGBA) *image.RGBA {
+	bounds := i.Bounds()
+	rect := c.Rect.Add(bounds.Min).Intersect(bounds)
+	if rect.Empty() {
+		return i
+	}
+	return copyRegion(i, rect)
+}
+
+func (c Crop) String() string {
+	return fmt.Sprintf("crop(%d,%d,%d,%d)", c.Rect.Min.X, c.Rect.Min.Y, c.Rect.Dx(), c.Rect.Dy())
+}
+
+type Trim struct {
+	Threshold uint8
+}
+
+func (t Trim) Apply(i *image.RGBA) *image.RGBA {
+	rect := OpaqueBounds(i, t.Threshold)
+	if rect.Empty() || rect == i.Bounds() {
+		return i
+	}
+	return copyRegion(i, rect)
+}
+
+func (t Trim) String() string {
+	return fmt.Sprintf("trim(%d)", t.Threshold)
+}
+
+type Flip struct {
+	Horizontal bool
+	Vertical   bool
+}
+
+func (f Flip) Apply(i *image.RGBA) *image.RGBA {
+	if !f.Horizontal && !f.Vertical {
+		return i
+	}
+
+	bounds := i.Bounds()
+	width, height := bounds.Dx(), bounds.Dy()
+	dst := image.NewRGBA(image.Rect(0, 0, width, height))
+
+	for y := range height {
+		sy := y
+		if f.Vertical {
+			sy = height - 1 - y
+		}
+
+		offset := i.PixOffset(bounds.Min.X, bounds.Min.Y+sy)
+		src := i.Pix[offset : offset+width*4]
+		out := dst.Pix[y*dst.Stride : y*dst.Stride+width*4]
+
+		if !f.Horizontal {
+			copy(out, src)
+			continue
+		}
+		for x := range width {
+			copy(out[x*4:x*4+4], src[(width-1-x)*4:(width-x)*4])
+		}
+	}
+
+	return dst
+}
+
+func (f Flip) String() string {
+	return fmt.Sprintf("flip(%t,%t)", f.Horizontal, f.Vertical)
+}
+
+type Rotate struct {
+	Degrees float64
+}
+
+func (r Rotate) Apply(i *image.RGBA) *image.RGBA {
+	degrees := math.Mod(r.Degrees, 360)
+	if degrees < 0 {
+		degrees += 360
+	}
+
+	switch degrees {
+	case 0:
+		return i
+	case 90, 180, 270:
+		return rotateQuarter(i, int(degrees/90))
+	}
+
+	bounds := i.Bounds()
+	width, height := float64(bounds.Dx()), float64(bounds.Dy())
+
+	radians := degrees * math.Pi / 180
+	sin, cos := math.Sincos(radians)
+
+	newWidth := int(math.Ceil(math.Abs(width*cos) + math.Abs(height*sin) - 1e-9))
+	newHeight := int(math.Ceil(math.Abs(width*sin) + math.Abs(height*cos) - 1e-9))
+	dst := image.NewRGBA(image.Rect(0, 0, newWidth, newHeight))
+
+	parallel(newHeight, bandCount(newHeight), func(_, from, to int) {
+		for y := from; y < to; y++ {
+			dy := float64(y) + 0.5 - float64(newHeight)/2
+			out := dst.Pix[y*dst.Stride : y*dst.Stride+newWidth*4]
+
+			for x := range newWidth {
+				dx := float64(x) + 0.5 - float64(newWidth)/2
+
+				sx := dx*cos + dy*sin + width/2 - 0.5
+				sy := -dx*sin + dy*cos + height/2 - 0.5
+
+				sampleBilinear(i, sx, sy, out[x*4:x*4+4])
+			}
+		}
+	})
+
+	return dst
+}
+
+func (r Rotate) String() string {
+	return fmt.Sprintf("rotate(%g)", r.Degrees)
+}
+
+type Pad struct {
+	Top, Right, Bottom, Left int
+}
+
+func (p Pad) Apply(i *image.RGBA) *image.RGBA {
+	if p.Top == 0 && p.Right == 0 && p.Bottom == 0 && p.Left == 0 {
+		return i
+	}
+
+	bounds := i.Bounds()
+	width := max(bounds.Dx()+p.Left+p.Right, 1)
+	height := max(bounds.Dy()+p.Top+p.Bottom, 1)
+
+	dst := image.NewRGBA(image.Rect(0, 0, width, height))
+	draw.Draw(dst, bounds.Sub(bounds.Min).Add(image.Pt(p.Left, p.Top)), i, bounds.Min, draw.Src)
+
+	return dst
+}
+
+func (p Pad) String() string {
+	return fmt.Sprintf("pad(%d,%d,%d,%d)", p.Top, p.Right, p.Bottom, p.Left)
+}
+
+func OpaqueBounds(i *image.RGBA, threshold uint8) image.Rectangle {
+	bounds := i.Bounds()
+	rect := image.Rectangle{}
+
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		offset := i.PixOffset(bounds.Min.X, y)
+		row := i.Pix[offset : offset+bounds.Dx()*4]
+
+		for x := 3; x < len(row); x += 4 {
+			if row[x] <= threshold {
+				continue
+			}
+
+			px := bounds.Min.X + x/4
+			if rect.Empty() {
+				rect = image.Rect(px, y, px+1, y+1)
+				continue
+			}
+			rect = rect.Union(image.Rect(px, y, px+1, y+1))
+		}
+	}
+
+	return rect
+}
+
+func rotateQuarter(i *image.RGBA, turns int) *image.RGBA {
+	bounds := i.Bounds()
+	width, height := bounds.Dx(), bounds.Dy()
+
+	newWidth, newHeight := width, height
+	if turns%2 == 1 {
+		newWidth, newHeight = height, width
+	}
+	dst := image.NewRGBA(image.Rect(0, 0, newWidth, newHeight))
+
+	for y := range height {
+		offset := i.PixOffset(bounds.Min.X, bounds.Min.Y+y)
+		row := i.Pix[offset : offset+width*4]
+
+		for x := range width {
+			var dx, dy int
+			switch turns {
+			case 1:
+				dx, dy = height-1-y, x
+			case 2:
+				dx, dy = width-1-x, height-1-y
+			case 3:
+				dx, dy = y, width-1-x
+			}
+			copy(dst.Pix[dst.PixOffset(dx, dy):], row[x*4:x*4+4])
+		}
+	}
+
+	return dst
+}
+
+func sampleBilinear(i *image.RGBA, x, y float64, out []uint8) {
+	bounds := i.Bounds()
+
+	x0, y0 := int(math.Floor(x)), int(math.Floor(y))
+	fx, fy := x-float64(x0), y-float64(y0)
+
+	var r, g, b, a float64
+	for _, tap := range [4]struct {
+		x, y   int
+		weight float64
+	}{
+		{x0, y0, (1 - fx) * (1 - fy)},
+		{x0 + 1, y0, fx * (1 - fy)},
+		{x0, y0 + 1, (1 - fx) * fy},
+		{x0 + 1, y0 + 1, fx * fy},
+	} {
+		if tap.weight == 0 || tap.x < 0 || tap.y < 0 || tap.x >= bounds.Dx() || tap.y >= bounds.Dy() {
+			continue
+		}
+
+		offset := i.PixOffset(bounds.Min.X+tap.x, bounds.Min.Y+tap.y)
+		p := i.Pix[offset : offset+4 : offset+4]
+		r += float64(p[0]) * tap.weight
+		g += float64(p[1]) * tap.weight
+		b += float64(p[2]) * tap.weight
+		a += float64(p[3]) * tap.weight
+	}
+
+	out[3] = uint8(math.Round(a))
+	out[0] = min(uint8(math.Round(r)), out[3])
+	out[1] = min(uint8(math.Round(g)), out[3])
+	out[2] = min(uint8(math.Round(b)), out[3])
+}
+
+func copyRegion(i *image.RGBA, rect image.Rectangle) *image.RGBA {
+	dst := image.NewRGBA(image.Rect(0, 0, rect.Dx(), rect.Dy()))
+	for y := range rect.Dy() {
+		offset := i.PixOffset(rect.Min.X, rect.Min.Y+y)
+		copy(dst.Pix[y*dst.Stride:], i.Pix[offset:offset+rect.Dx()*4])
+	}
+	return dst
+}
+
+func toRGBA(i image.Image) *image.RGBA {
+	if rgba, ok := i.(*image.RGBA); ok {
+		return rgba
+	}
+
+	bounds := i.Bounds()
+	rgba := image.NewRGBA(image.Rect(0, 0, bounds.Dx(), bounds.Dy()))
+	draw.Draw(rgba, rgba.Bounds(), i, bounds.Min, draw.Src)
+	return rgba
+}
@@ -0,0 +1,190 @@
+package images
+
+import (
+	"image"
+	"image/color"
+	"testing"
+)
+
+func markedImage(rect image.Rectangle) *image.RGBA {
+	img := image.NewRGBA(rect)
+	for y := rect.Min.Y; y < rect.Max.Y; y++ {
+		for x := rect.Min.X; x < rect.Max.X; x++ {
+			img.SetRGBA(x, y, color.RGBA{R: uint8(x - rect.Min.X), G: uint8(y - rect.Min.Y), A: 255})
+		}
+	}
+	return img
+}
+
+func TestCropClamps(t *testing.T) {
+	src := markedImage(image.Rect(10, 20, 14, 23))
+
+	tests := []struct {
+		name   string
+		rect   image.Rectangle
+		bounds image.Rectangle
+		first  color.RGBA
+	}{
+		{name: "inside", rect: image.Rect(1, 1, 3, 2), bounds: image.Rect(0, 0, 2, 1), first: color.RGBA{R: 1, G: 1, A: 255}},
+		{name: "past the bottom right", rect: image.Rect(2, 1, 10, 10), bounds: image.Rect(0, 0, 2, 2), first: color.RGBA{R: 2, G: 1, A: 255}},
+		{name: "before the top left", rect: image.Rect(-2, -2, 1, 1), bounds: image.Rect(0, 0, 1, 1), first: color.RGBA{A: 255}},
+		{name: "outside", rect: image.Rect(5, 5, 8, 8), bounds: src.Bounds(), first: color.RGBA{A: 255}},
+	}
+
+	for _, tt := range tests {
+		got := Crop{Rect: tt.rect}.Apply(src)
+		if got.Bounds() != tt.bounds {
+			t.Errorf("%s: bounds = %v, want %v", tt.name, got.Bounds(), tt.bounds)
+			continue
+		}
+		if pixel := got.RGBAAt(tt.bounds.Min.X, tt.bounds.Min.Y); pixel != tt.first {
+			t.Errorf("%s: first pixel = %v, want %v", tt.name, pixel, tt.first)
+		}
+	}
+}
+
+func TestTrim(t *testing.T) {
+	src := image.NewRGBA(image.Rect(0, 0, 6, 5))
+	src.SetRGBA(1, 1, color.RGBA{A: 10})
+	src.SetRGBA(2, 3, redPixel)
+	src.SetRGBA(4, 2, bluePixel)
+
+	if got := (Trim{}).Apply(src); got.Bounds() != image.Rect(0, 0, 4, 3) || got.RGBAAt(0, 0) != (color.RGBA{A: 10}) {
+		t.Errorf("trim(0) = %v, want the 4x3 box starting at the faint pixel", got.Bounds())
+	}
+	if got := (Trim{Threshold: 10}).Apply(src); got.Bounds() != image.Rect(0, 0, 3, 2) || got.RGBAAt(0, 1) != redPixel || got.RGBAAt(2, 0) != bluePixel {
+		t.Errorf("trim(10) = %v, want the 3x2 box around the opaque pixels", got.Bounds())
+	}
+	if got := (Trim{}).Apply(image.NewRGBA(image.Rect(0, 0, 3, 3))); got.Bounds() != image.Rect(0, 0, 3, 3) {
+		t.Errorf("trimming a transparent image gave %v, want it unchanged", got.Bounds())
+	}
+}
+
+func TestFlip(t *testing.T) {
+	src := markedImage(image.Rect(0, 0, 3, 2))
+
+	tests := []struct {
+		flip Flip
+		want func(x, y int) (int, int)
+	}{
+		{flip: Flip{Horizontal: true}, want: func(x, y int) (int, int) { return 2 - x, y }},
+		{flip: Flip{Vertical: true}, want: func(x, y int) (int, int) { return x, 1 - y }},
+		{flip: Flip{Horizontal: true, Vertical: true}, want: func(x, y int) (int, int) { return 2 - x, 1 - y }},
+	}
+
+	for _, tt := range tests {
+		got := tt.flip.Apply(src)
+		for y := range 2 {
+			for x := range 3 {
+				sx, sy := tt.want(x, y)
+				if got.RGBAAt(x, y) != src.RGBAAt(sx, sy) {
+					t.Fatalf("%s: pixel (%d, %d) = %v, want %v", tt.flip, x, y, got.RGBAAt(x, y), src.RGBAAt(sx, sy))
+				}
+			}
+		}
+	}
+}
+
+func TestRotateQuarterTurns(t *testing.T) {
+	src := markedImage(image.Rect(5, 5, 8, 7))
+
+	tests := []struct {
+		degrees float64
+		bounds  image.Rectangle
+		want    func(x, y int) (int, int)
+	}{
+		{degrees: 90, bounds: image.Rect(0, 0, 2, 3), want: func(x, y int) (int, int) { return y, 1 - x }},
+		{degrees: -270, bounds: image.Rect(0, 0, 2, 3), want: func(x, y int) (int, int) { return y, 1 - x }},
+		{degrees: 180, bounds: image.Rect(0, 0, 3, 2), want: func(x, y int) (int, int) { return 2 - x, 1 - y }},
+		{degrees: 270, bounds: image.Rect(0, 0, 2, 3), want: func(x, y int) (int, int) { return 2 - y, x }},
+		{degrees: -90, bounds: image.Rect(0, 0, 2, 3), want: func(x, y int) (int, int) { return 2 - y, x }},
+	}
+
+	for _, tt := range tests {
+		got := Rotate{Degrees: tt.degrees}.Apply(src)
+		if got.Bounds() != tt.bounds {
+			t.Errorf("rotate(%g) bounds = %v, want %v", tt.degrees, got.Bounds(), tt.bounds)
+			continue
+		}
+		for y := range tt.bounds.Dy() {
+			for x := range tt.bounds.Dx() {
+				sx, sy := tt.want(x, y)
+				if want := src.RGBAAt(5+sx, 5+sy); got.RGBAAt(x, y) != want {
+					t.Fatalf("rotate(%g): pixel (%d, %d) = %v, want %v", tt.degrees, x, y, got.RGBAAt(x, y), want)
+				}
+			}
+		}
+	}
+
+	if got := (Rotate{Degrees: 360}).Apply(src); got != src {
+		t.Error("rotate(360) did not return the image unchanged")
+	}
+}
+
+func TestRotateArbitraryAngle(t *testing.T) {
+	src := image.NewRGBA(image.Rect(0, 0, 10, 2))
+	for y := range 2 {
+		for x := range 10 {
+			c := bluePixel
+			if x < 5 {
+				c = redPixel
+			}
+			src.SetRGBA(x, y, c)
+		}
+	}
+
+	centroid := func(img *image.RGBA, channel int) float64 {
+		var sum, total float64
+		for y := img.Rect.Min.Y; y < img.Rect.Max.Y; y++ {
+			for x := img.Rect.Min.X; x < img.Rect.Max.X; x++ {
+				v := float64(img.Pix[img.PixOffset(x, y)+channel])
+				sum += v * float64(y)
+				total += v
+			}
+		}
+		return sum / total
+	}
+
+	clockwise := Rotate{Degrees: 30}.Apply(src)
+	if clockwise.Bounds() != image.Rect(0, 0, 10, 7) {
+		t.Fatalf("rotate(30) bounds = %v, want 10x7", clockwise.Bounds())
+	}
+	if red, blue := centroid(clockwise, 0), centroid(clockwise, 2); red >= blue {
+		t.Errorf("rotate(30) left half at y %.2f, right half at y %.2f, want the right half lower", red, blue)
+	}
+	if corner := clockwise.RGBAAt(0, 6); corner != clearPixel {
+		t.Errorf("rotate(30) corner = %v, want transparent", corner)
+	}
+
+	counter := Rotate{Degrees: -30}.Apply(src)
+	if red, blue := centroid(counter, 0), centroid(counter, 2); red <= blue {
+		t.Errorf("rotate(-30) left half at y %.2f, right half at y %.2f, want the left half lower", red, blue)
+	}
+}
+
+func TestPad(t *testing.T) {
+	src := markedImage(image.Rect(3, 3, 5, 5))
+
+	got := Pad{Top: 1, Right: 2, Bottom: 3, Left: 4}.Apply(src)
+	if got.Bounds() != image.Rect(0, 0, 8, 6) {
+		t.Fatalf("bounds = %v, want 8x6", got.Bounds())
+	}
+	if got.RGBAAt(4, 1) != src.RGBAAt(3, 3) || got.RGBAAt(5, 2) != src.RGBAAt(4, 4) {
+		t.Error("image not placed at the left and top padding")
+	}
+	if got.RGBAAt(3, 1) != clearPixel || got.RGBAAt(6, 2) != clearPixel || got.RGBAAt(4, 3) != clearPixel {
+		t.Error("padding is not transparent")
+	}
+}
+
+func TestPipeline(t *testing.T) {
+	pipeline := Pipeline{Crop{Rect: image.Rect(0, 0, 2, 1)}, Rotate{Degrees: 90}, Pad{Left: 1}}
+
+	got := pipeline.Apply(markedImage(image.Rect(0, 0, 3, 3)))
+	if got.Bounds() != image.Rect(0, 0, 2, 2) || got.RGBAAt(1, 1) != (color.RGBA{R: 1, A: 255}) {
+		t.Errorf("pipeline gave %v with %v at (1, 1)", got.Bounds(), got.RGBAAt(1, 1))
+	}
+	if s := pipeline.String(); s != "crop(0,0,2,1),rotate(90),pad(0,0,0,1)" {
+		t.Errorf("String() = %q", s)
+	}
+}
@@ -0,0 +1,227 @@
+package images
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"image"
+	"image/draw"
+
+	"golang.org/x/image/webp"
+)
+
+const (
+	webpAnimationFlag = 1 << 1
+	webpAlphaFlag     = 1 << 4
+
+	webpDisposeBackground = 1 << 0
+	webpNoBlend           = 1 << 1
+)
+
+type riffChunk struct {
+	kind string
+	data []byte
+}
+
+func IsAnimatedWebP(data []byte) bool {
+	chunks, err := readWebPChunks(data)
+	if err != nil || len(chunks) == 0 {
+		return false
+	}
+
+	first := chunks[0]
+	return first.kind == "VP8X" && len(first.data) >= 10 && first.data[0]&webpAnimationFlag != 0
+}
+
+func DecodeWebP(data []byte) (*Animation, error) {
+	chunks, err := readWebPChunks(data)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(chunks) == 0 || chunks[0].kind != "VP8X" {
+		img, err := webp.Decode(bytes.NewReader(data))
+		if err != nil {
+			return nil, err
+		}
+		return stillAnimation(img), nil
+	}
+
+	header := chunks[0].data
+	if len(header) < 10 {
+		return nil, errors.New("webp: invalid VP8X chunk")
+	}
+	if header[0]&webpAnimationFlag == 0 {
+		img, err := webp.Decode(bytes.NewReader(data))
+		if err != nil {
+			return nil, err
+		}
+		return stillAnimation(img), nil
+	}
+
+	canvasBounds := image.Rect(0, 0, int(readUint24(header[4:7]))+1, int(readUint24(header[7:10]))+1)
+	canvas := image.NewRGBA(canvasBounds)
+
+	animation := &Animation{}
+
+	for _, chunk := range chunks[1:] {
+		switch chunk.kind {
+		case "ANIM":
+			if len(chunk.data) < 6 {
+				return nil, errors.New("webp: invalid ANIM chunk")
+			}
+			animation.Plays = int(binary.LittleEndian.Uint16(chunk.data[4:6]))
+		case "ANMF":
+			if len(chunk.data) < 16 {
+				return nil, errors.New("webp: invalid ANMF chunk")
+			}
+
+			x := int(readUint24(chunk.data[0:3])) * 2
+			y := int(readUint24(chunk.data[3:6])) * 2
+			width := int(readUint24(chunk.data[6:9])) + 1
+			height := int(readUint24(chunk.data[9:12])) + 1
+			duration := int(readUint24(chunk.data[12:15]))
+			flags := chunk.data[15]
+
+			img, err := decodeWebPFrame(chunk.data[16:], width, height)
+			if err != nil {
+				return nil, fmt.Errorf("webp: frame %d: %w", len(animation.Frames), err)
+			}
+
+			region := image.Rect(x, y, x+width, y+height).Intersect(canvasBounds)
+
+			op := draw.Over
+			if flags&webpNoBlend != 0 {
+				op = draw.Src
+			}
+			draw.Draw(canvas, region, img, img.Bounds().Min, op)
+
+			frameImg := image.NewRGBA(canvasBounds)
+			copy(frameImg.Pix, canvas.Pix)
+			animation.Frames = append(animation.Frames, frameImg)
+			animation.Delays = append(animation.Delays, duration)
+
+			if flags&webpDisposeBackground != 0 {
+				draw.Draw(canvas, region, image.Transparent, image.Point{}, draw.Src)
+			}
+		}
+	}
+
+	if len(animation.Frames) == 0 {
+		return nil, errors.New("webp: animation has no frames")
+	}
+
+	return animation, nil
+}
+
+func decodeWebPFrame(data []byte, width, height int) (image.Image, error) {
+	chunks, err := readRIFFChunks(data)
+	if err != nil {
+		return nil, err
+	}
+
+	var flags byte
+	var body bytes.Buffer
+
+	for _, chunk := range chunks {
+		switch chunk.kind {
+		case "ALPH":
+			flags |= webpAlphaFlag
+			writeRIFFChunk(&body, chunk.kind, chunk.data)
+		case "VP8 ", "VP8L":
+			writeRIFFChunk(&body, chunk.kind, chunk.data)
+		}
+	}
+
+	header := make([]byte, 10)
+	header[0] = flags
+	putUint24(header[4:7], uint32(width-1))
+	putUint24(header[7:10], uint32(height-1))
+
+	var payload bytes.Buffer
+	payload.WriteString("WEBP")
+	writeRIFFChunk(&payload, "VP8X", header)
+	payload.Write(body.Bytes())
+
+	var buf bytes.Buffer
+	buf.WriteString("RIFF")
+	var size [4]byte
+	binary.LittleEndian.PutUint32(size[:], uint32(payload.Len()))
+	buf.Write(size[:])
+	buf.Write(payload.Bytes())
+
+	return webp.Decode(&buf)
+}
+
+func stillAnimation(img image.Image) *Animation {
+	bounds := img.Bounds()
+	frame := image.NewRGBA(image.Rect(0, 0, bounds.Dx(), bounds.Dy()))
+	draw.Draw(frame, frame.Bounds(), img, bounds.Min, draw.Src)
+
+	return &Animation{
+		Frames: []*image.RGBA{frame},
+		Delays: []int{0},
+	}
+}
+
+func readWebPChunks(data []byte) ([]riffChunk, error) {
+	if len(data) < 12 || string(data[0:4]) != "RIFF" || string(data[8:12]) != "WEBP" {
+		return nil, errors.New("webp: invalid signature")
+	}
+
+	size := int(binary.LittleEndian.Uint32(data[4:8]))
+	if size < 4 {
+		return nil, errors.New("webp: invalid RIFF size")
+	}
+	end := min(8+size, len(data))
+
+	return readRIFFChunks(data[12:end])
+}
+
+func readRIFFChunks(data []byte) ([]riffChunk, error) {
+	var chunks []riffChunk
+
+	offset := 0
+	for offset+8 <= len(data) {
+		kind := string(data[offset : offset+4])
+		length := int(binary.LittleEndian.Uint32(data[offset+4 : offset+8]))
+		start := offset + 8
+
+		if length < 0 || start+length > len(data) {
+			return nil, fmt.Errorf("webp: truncated %s chunk", kind)
+		}
+
+		chunks = append(chunks, riffChunk{
+			kind: kind,
+			data: data[start : start+length],
+		})
+
+		offset = start + length + length&1
+	}
+
+	return chunks, nil
+}
+
+func writeRIFFChunk(buf *bytes.Buffer, kind string, data []byte) {
+	var length [4]byte
+	binary.LittleEndian.PutUint32(length[:], uint32(len(data)))
+
+	buf.WriteString(kind)
+	buf.Write(length[:])
+	buf.Write(data)
+
+	if len(data)&1 == 1 {
+		buf.WriteByte(0)
+	}
+}
+
+func readUint24(b []byte) uint32 {
+	return uint32(b[0]) | uint32(b[1])<<8 | uint32(b[2])<<16
+}
+
+func putUint24(b []byte, v uint32) {
+	b[0] = byte(v)
+	b[1] = byte(v >> 8)
+	b[2] = byte(v >> 16)
+}
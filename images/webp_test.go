@@ -0,0 +1,271 @@
+package images
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"image"
+	"image/color"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"golang.org/x/image/webp"
+)
+
+type bitWriter struct {
+	data []byte
+	bits int
+}
+
+func (w *bitWriter) write(value uint32, n int) {
+	for i := range n {
+		if w.bits%8 == 0 {
+			w.data = append(w.data, 0)
+		}
+		w.data[len(w.data)-1] |= byte(value>>i&1) << (w.bits % 8)
+		w.bits++
+	}
+}
+
+func encodeVP8L(t *testing.T, img *image.NRGBA) []byte {
+	t.Helper()
+
+	size := img.Bounds().Size()
+	w := &bitWriter{}
+	w.write(0x2f, 8)
+	w.write(uint32(size.X-1), 14)
+	w.write(uint32(size.Y-1), 14)
+	w.write(1, 1)
+	w.write(0, 3)
+	w.write(0, 1)
+	w.write(0, 1)
+	w.write(0, 1)
+
+	channels := []int{1, 0, 2, 3}
+	symbols := make([][]uint8, len(channels))
+	for i, channel := range channels {
+		for p := channel; p < len(img.Pix); p += 4 {
+			if !slices.Contains(symbols[i], img.Pix[p]) {
+				symbols[i] = append(symbols[i], img.Pix[p])
+			}
+		}
+		if len(symbols[i]) > 2 {
+			t.Fatalf("channel %d uses more than two values", channel)
+		}
+
+		w.write(1, 1)
+		w.write(uint32(len(symbols[i])-1), 1)
+		w.write(1, 1)
+		for _, symbol := range symbols[i] {
+			w.write(uint32(symbol), 8)
+		}
+	}
+	w.write(1, 1)
+	w.write(0, 1)
+	w.write(0, 1)
+	w.write(0, 1)
+
+	for p := 0; p < len(img.Pix); p += 4 {
+		for i, channel := range channels {
+			if len(symbols[i]) == 2 && img.Pix[p+channel] == symbols[i][1] {
+				w.write(1, 1)
+			} else if len(symbols[i]) == 2 {
+				w.write(0, 1)
+			}
+		}
+	}
+
+	return w.data
+}
+
+func nrgbaImage(width, height int, pixels map[image.Point]color.NRGBA, fill color.NRGBA) *image.NRGBA {
+	img := image.NewNRGBA(image.Rect(0, 0, width, height))
+	for y := range height {
+		for x := range width {
+			c, ok := pixels[image.Pt(x, y)]
+			if !ok {
+				c = fill
+			}
+			img.SetNRGBA(x, y, c)
+		}
+	}
+	return img
+}
+
+func webpFile(chunks ...riffChunk) []byte {
+	var payload bytes.Buffer
+	payload.WriteString("WEBP")
+	for _, chunk := range chunks {
+		writeRIFFChunk(&payload, chunk.kind, chunk.data)
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString("RIFF")
+	binary.Write(&buf, binary.LittleEndian, uint32(payload.Len()))
+	buf.Write(payload.Bytes())
+	return buf.Bytes()
+}
+
+func vp8xChunk(flags byte, width, height int) riffChunk {
+	data := make([]byte, 10)
+	data[0] = flags
+	putUint24(data[4:7], uint32(width-1))
+	putUint24(data[7:10], uint32(height-1))
+	return riffChunk{kind: "VP8X", data: data}
+}
+
+func anmfChunk(at image.Point, width, height, duration int, flags byte, frame ...riffChunk) riffChunk {
+	data := make([]byte, 16)
+	putUint24(data[0:3], uint32(at.X/2))
+	putUint24(data[3:6], uint32(at.Y/2))
+	putUint24(data[6:9], uint32(width-1))
+	putUint24(data[9:12], uint32(height-1))
+	putUint24(data[12:15], uint32(duration))
+	data[15] = flags
+
+	var buf bytes.Buffer
+	buf.Write(data)
+	for _, chunk := range frame {
+		writeRIFFChunk(&buf, chunk.kind, chunk.data)
+	}
+	return riffChunk{kind: "ANMF", data: buf.Bytes()}
+}
+
+func TestReadWebPChunksRejectsShortRIFFSize(t *testing.T) {
+	data := []byte("RIFF\x00\x00\x00\x00WEBPxxxx")
+
+	if IsAnimatedWebP(data) {
+		t.Fatal("corrupt file reported as animated")
+	}
+	if _, err := DecodeWebP(data); err == nil {
+		t.Fatal("expected an error for a RIFF size below 4")
+	}
+}
+
+func TestDecodeWebPLossless(t *testing.T) {
+	img := nrgbaImage(2, 2, map[image.Point]color.NRGBA{
+		image.Pt(0, 0): {R: 255, A: 255},
+		image.Pt(1, 0): {G: 255, A: 255},
+		image.Pt(0, 1): {R: 255},
+	}, color.NRGBA{G: 255})
+
+	animation, err := DecodeWebP(webpFile(riffChunk{kind: "VP8L", data: encodeVP8L(t, img)}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(animation.Frames) != 1 {
+		t.Fatalf("decoded %d frames, want 1", len(animation.Frames))
+	}
+
+	frame := animation.Frames[0]
+	for at, want := range map[image.Point]color.RGBA{
+		image.Pt(0, 0): redPixel,
+		image.Pt(1, 0): greenPixel,
+		image.Pt(0, 1): clearPixel,
+		image.Pt(1, 1): clearPixel,
+	} {
+		if got := frame.RGBAAt(at.X, at.Y); got != want {
+			t.Errorf("pixel %v = %v, want %v", at, got, want)
+		}
+	}
+}
+
+func TestDecodeWebPAnimation(t *testing.T) {
+	red := nrgbaImage(4, 4, nil, color.NRGBA{R: 255, A: 255})
+	green := nrgbaImage(2, 2, map[image.Point]color.NRGBA{image.Pt(0, 0): {G: 255, A: 255}}, color.NRGBA{})
+	blue := nrgbaImage(2, 2, map[image.Point]color.NRGBA{image.Pt(0, 0): {B: 255, A: 255}}, color.NRGBA{})
+
+	data := webpFile(
+		vp8xChunk(webpAnimationFlag|webpAlphaFlag, 4, 4),
+		riffChunk{kind: "ANIM", data: []byte{0, 0, 0, 0, 3, 0}},
+		anmfChunk(image.Pt(0, 0), 4, 4, 100, 0, riffChunk{kind: "VP8L", data: encodeVP8L(t, red)}),
+		anmfChunk(image.Pt(2, 2), 2, 2, 50, webpDisposeBackground, riffChunk{kind: "VP8L", data: encodeVP8L(t, green)}),
+		anmfChunk(image.Pt(0, 0), 2, 2, 70, webpNoBlend, riffChunk{kind: "VP8L", data: encodeVP8L(t, blue)}),
+	)
+
+	if !IsAnimatedWebP(data) {
+		t.Fatal("animation not detected")
+	}
+
+	animation, err := DecodeWebP(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fmt.Sprint(animation.Delays) != "[100 50 70]" || animation.Plays != 3 {
+		t.Fatalf("delays = %v, plays = %d, want [100 50 70], 3", animation.Delays, animation.Plays)
+	}
+
+	checks := []pixelCheck{
+		{frame: 0, at: image.Pt(3, 3), want: redPixel},
+		{frame: 1, at: image.Pt(2, 2), want: greenPixel},
+		{frame: 1, at: image.Pt(3, 2), want: redPixel},
+		{frame: 1, at: image.Pt(3, 3), want: redPixel},
+		{frame: 1, at: image.Pt(0, 0), want: redPixel},
+		{frame: 2, at: image.Pt(0, 0), want: bluePixel},
+		{frame: 2, at: image.Pt(1, 0), want: clearPixel},
+		{frame: 2, at: image.Pt(1, 1), want: clearPixel},
+		{frame: 2, at: image.Pt(2, 2), want: clearPixel},
+		{frame: 2, at: image.Pt(3, 3), want: clearPixel},
+		{frame: 2, at: image.Pt(3, 0), want: redPixel},
+		{frame: 2, at: image.Pt(0, 3), want: redPixel},
+	}
+	for _, check := range checks {
+		if got := animation.Frames[check.frame].RGBAAt(check.at.X, check.at.Y); got != check.want {
+			t.Errorf("frame %d at %v = %v, want %v", check.frame, check.at, got, check.want)
+		}
+	}
+}
+
+func TestDecodeWebPLossyAlpha(t *testing.T) {
+	data, err := os.ReadFile(filepath.Join("testdata", "lossy.webp"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	reference, err := webp.Decode(bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	chunks, err := readWebPChunks(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	vp8 := chunks[0]
+
+	size := reference.Bounds().Size()
+	alpha := riffChunk{kind: "ALPH", data: make([]byte, 1+size.X*size.Y)}
+	for y := range size.Y {
+		for x := range size.X {
+			alpha.data[1+y*size.X+x] = uint8(x + y)
+		}
+	}
+
+	files := map[string][]byte{
+		"still":    webpFile(vp8xChunk(webpAlphaFlag, size.X, size.Y), alpha, vp8),
+		"animated": webpFile(vp8xChunk(webpAnimationFlag|webpAlphaFlag, size.X, size.Y), anmfChunk(image.Point{}, size.X, size.Y, 100, 0, alpha, vp8)),
+	}
+
+	for name, file := range files {
+		t.Run(name, func(t *testing.T) {
+			animation, err := DecodeWebP(file)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			frame := animation.Frames[0]
+			if frame.Bounds().Size() != size {
+				t.Fatalf("frame size = %v, want %v", frame.Bounds().Size(), size)
+			}
+			for y := range size.Y {
+				for x := range size.X {
+					ycbcr := reference.(*image.YCbCr).YCbCrAt(x, y)
+					want := color.RGBAModel.Convert(color.NYCbCrA{YCbCr: ycbcr, A: uint8(x + y)}).(color.RGBA)
+					if got := frame.RGBAAt(x, y); got != want {
+						t.Fatalf("pixel (%d, %d) = %v, want %v", x, y, got, want)
+					}
+				}
+			}
+		})
+	}
+}
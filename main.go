@@ -4,8 +4,15 @@ import (
 	"context"
 	"fmt"
 	"image"
+	"image/color"
 	_ "image/jpeg"
 	_ "image/png"
+	"os"
+	"slices"
+	"sync"
+	"time"
+
+	_ "golang.org/x/image/webp"
 
 	"github.com/fluffy-melli/visualio/config"
 	"github.com/fluffy-melli/visualio/cursor"
@@ -20,6 +27,24 @@ var ConfigFile = "config.toml"
 var ErrorLogs = "error.log"
 
 func main() {
+	if len(os.Args) > 1 {
+		var command func([]string) error
+		switch os.Args[1] {
+		case "render":
+			command = renderCommand
+		case "config":
+			command = configCommand
+		}
+
+		if command != nil {
+			if err := command(os.Args[2:]); err != nil {
+				fmt.Fprintln(os.Stderr, err)
+				os.Exit(1)
+			}
+			return
+		}
+	}
+
 	logs := log.NewLogger(ErrorLogs)
 
 	configs, err := config.Load(ConfigFile)
@@ -53,59 +78,328 @@ func main() {
 		}
 	}
 
-	Xunit, found := strings.ExtractNumber(configs.ImageResize.Width)
-	if !found {
-		logs.Panic("X resize value not found")
+	overlays := configs.AllOverlays()
+	if len(overlays) == 0 {
+		logs.Panic(fmt.Errorf("config has no image source"))
+	}
+
+	watcher, err := config.NewWatcher(ConfigFile, configs)
+	if err != nil {
+		logs.Panic(err)
+	}
+
+	var configMu sync.Mutex
+	var screens []*graphics.Render
+
+	scene := graphics.NewScene()
+
+	for index, overlay := range overlays {
+		screen, err := newOverlay(configs, overlay)
+		if err != nil {
+			logs.Panic(fmt.Errorf("%s: %w", overlayLabel(index, overlay), err))
+		}
+
+		screen.OnUpMButton = func(r *graphics.Render) {
+			configMu.Lock()
+			defer configMu.Unlock()
+
+			x, y := r.Position()
+			configs.SetOverlayPosition(index, x, y)
+			watcher.Schedule(configs)
+		}
+
+		screen.OnDownMButton = func(r *graphics.Render) {}
+
+		position := make(chan cursor.Location)
+
+		screen.Routines = make([]func(s *graphics.Render), 0)
+
+		screen.Routines = append(screen.Routines, cursor.PositionReader(ctx, position))
+		screen.Routines = append(screen.Routines, cursor.DeltaHandler(ctx, position))
+
+		if err := screen.Load(overlay.Image.Source); err != nil {
+			logs.Panic(fmt.Errorf("%s: %w", overlayLabel(index, overlay), err))
+		}
+
+		scene.Add(screen)
+		screens = append(screens, screen)
+	}
+
+	watcher.OnError = func(err error) {
+		logs.Println(err)
+	}
+
+	watcher.OnChange = func(previous, current *config.Config) {
+		configMu.Lock()
+		*configs = *current
+		configMu.Unlock()
+
+		scene.Post(func() {
+			if err := applyConfig(screens, previous, current); err != nil {
+				logs.Println(err)
+			}
+		})
+	}
+
+	go watcher.Run(ctx)
+
+	err = scene.CreateWindow("visualio")
+
+	if flushErr := watcher.Flush(); flushErr != nil {
+		logs.Println(flushErr)
+	}
+
+	if err != nil {
+		logs.Panic(err)
+	}
+}
+
+func overlayLabel(index int, overlay config.Overlay) string {
+	if overlay.Name != "" {
+		return fmt.Sprintf("overlay %q", overlay.Name)
+	}
+	return fmt.Sprintf("overlay %d", index+1)
+}
+
+func newOverlay(configs *config.Config, overlay config.Overlay) (*graphics.Render, error) {
+	if overlay.ImageResize.Width == "" {
+		overlay.ImageResize.Width = "100%"
+	}
+	if overlay.ImageResize.Height == "" {
+		overlay.ImageResize.Height = "100%"
+	}
+
+	sizeMode, err := images.ParseSizeMode(overlay.ImageResize.Mode)
+	if err != nil {
+		return nil, err
+	}
+
+	var Xunit, Yunit strings.Dimension
+
+	if sizeMode.UsesWidth() {
+		Xunit, err = strings.ParseDimension(overlay.ImageResize.Width)
+		if err != nil {
+			return nil, fmt.Errorf("image-resize.width: %w", err)
+		}
+	}
+
+	if sizeMode.UsesHeight() {
+		Yunit, err = strings.ParseDimension(overlay.ImageResize.Height)
+		if err != nil {
+			return nil, fmt.Errorf("image-resize.height: %w", err)
+		}
+	}
+
+	filter, err := images.ParseFilter(overlay.ImageResize.Filter)
+	if err != nil {
+		return nil, err
 	}
 
-	Yunit, found := strings.ExtractNumber(configs.ImageResize.Height)
-	if !found {
-		logs.Panic("Y resize value not found")
+	mode, err := images.ParsePlaybackMode(overlay.Animation.Mode)
+	if err != nil {
+		return nil, err
+	}
+
+	delayPolicy, err := images.ParseDelayPolicy(overlay.Animation.DelayPolicy)
+	if err != nil {
+		return nil, err
+	}
+
+	pipeline, filters, err := buildPipeline(overlay.Transforms)
+	if err != nil {
+		return nil, err
 	}
 
 	screen := graphics.NewScreen()
 
-	screen.AX = configs.ImagePosition.X
-	screen.AY = configs.ImagePosition.Y
+	screen.Playback = graphics.Playback{
+		Mode:        mode,
+		Loops:       overlay.Animation.Loops,
+		Start:       overlay.Animation.Start,
+		End:         overlay.Animation.End,
+		Speed:       overlay.Animation.Speed,
+		DelayPolicy: delayPolicy,
+		MinDelay:    time.Duration(overlay.Animation.MinDelay) * time.Millisecond,
+	}
 
-	screen.OnUpMButton = func(r *graphics.Render) {
-		configs.ImagePosition.X = r.AX
-		configs.ImagePosition.Y = r.AY
-		config.Save(ConfigFile, configs)
+	screen.OnFinish = func(r *graphics.Render) {
+		if overlay.Animation.ExitOnFinish {
+			r.Close()
+		}
 	}
 
-	screen.OnDownMButton = func(r *graphics.Render) {}
+	screen.Name = overlay.Name
+	screen.ZIndex = overlay.ZIndex
+	screen.Locked = overlay.Locked
+	screen.SetPosition(overlay.ImagePosition.X, overlay.ImagePosition.Y)
+
+	screen.ProcessorKey = overlay.ImageResize.Width + "x" + overlay.ImageResize.Height + ":" + sizeMode.String() + ":" + filter.Name + ":" + pipeline.String() + ":" + filters.String()
+	screen.CacheBudget = int64(configs.Cache.MemoryBudget) << 20
+	screen.MemoryLimit = int64(configs.Cache.FrameMemoryLimit) << 20
+
+	resizer := images.NewResizer(filter)
 
 	screen.OnImage = func(r *graphics.Render, i image.Image) image.Image {
-		bounds := i.Bounds()
+		if len(pipeline) > 0 {
+			i = pipeline.Apply(i)
+		}
 
-		var newWidth, newHeight int
+		bounds := i.Bounds()
 
-		if Xunit.Unit == "%" {
-			newWidth = int(float64(bounds.Dx()) * Xunit.Value / 100.0)
-		} else {
-			newWidth = int(Xunit.Value)
+		screenWidth, screenHeight := r.ScreenSize()
+		viewport := strings.Viewport{
+			Width:  float64(screenWidth),
+			Height: float64(screenHeight),
+			DPI:    float64(r.DPI()),
 		}
 
-		if Yunit.Unit == "%" {
-			newHeight = int(float64(bounds.Dy()) * Yunit.Value / 100.0)
+		viewport.Reference = float64(bounds.Dx())
+		newWidth := int(Xunit.Resolve(viewport))
+
+		viewport.Reference = float64(bounds.Dy())
+		newHeight := int(Yunit.Resolve(viewport))
+
+		size := images.ResolveSize(image.Pt(bounds.Dx(), bounds.Dy()), newWidth, newHeight, sizeMode)
+
+		var resized *image.RGBA
+		if sizeMode != images.SizeCover && len(filters) == 0 {
+			resized = resizer.Resize(i, size.X, size.Y)
 		} else {
-			newHeight = int(Yunit.Value)
+			resized = resizer.ResizeThen(i, size.X, size.Y, func(resized *image.RGBA) *image.RGBA {
+				if sizeMode == images.SizeCover {
+					resized = images.CropCenter(resized, newWidth, newHeight)
+				}
+				if len(filters) > 0 {
+					resized = filters.Apply(resized)
+				}
+				return resized
+			})
 		}
 
-		return images.Resize(i, newWidth, newHeight)
+		resized.Rect = resized.Rect.Add(images.ScaleOrigin(bounds, size))
+		return resized
 	}
 
-	position := make(chan cursor.Location)
+	return screen, nil
+}
 
-	screen.Routines = make([]func(s *graphics.Render), 0)
+func buildPipeline(transforms []config.Transform) (images.Pipeline, images.Pipeline, error) {
+	var before, after images.Pipeline
+	pipeline := &after
+	resized := false
 
-	screen.Routines = append(screen.Routines, cursor.PositionReader(ctx, position))
-	screen.Routines = append(screen.Routines, cursor.DeltaHandler(ctx, position))
+	implicit := -1
+	if !slices.ContainsFunc(transforms, func(t config.Transform) bool { return t.Type == "resize" }) {
+		implicit = slices.IndexFunc(transforms, func(t config.Transform) bool { return isEffect(t.Type) })
+	}
 
-	err = screen.CreateWindow("visualio", configs.Image.Source)
+	for index, t := range transforms {
+		if index == implicit {
+			before, after, resized = after, nil, true
+		}
 
-	if err != nil {
-		logs.Panic(err)
+		amount := 1.0
+		if t.Amount != nil {
+			amount = *t.Amount
+		}
+
+		var transform images.Transform
+
+		switch t.Type {
+		case "resize":
+			if resized {
+				return nil, nil, fmt.Errorf("transform %d: resize can only appear once", index+1)
+			}
+			before, after, resized = after, nil, true
+			continue
+		case "crop":
+			if t.Width <= 0 || t.Height <= 0 {
+				return nil, nil, fmt.Errorf("transform %d: crop needs a positive width and height", index+1)
+			}
+			transform = images.Crop{Rect: image.Rect(t.X, t.Y, t.X+t.Width, t.Y+t.Height)}
+		case "trim":
+			if t.Threshold < 0 || t.Threshold > 255 {
+				return nil, nil, fmt.Errorf("transform %d: trim threshold must be between 0 and 255", index+1)
+			}
+			transform = images.Trim{Threshold: uint8(t.Threshold)}
+		case "flip":
+			transform = images.Flip{Horizontal: t.Horizontal, Vertical: t.Vertical}
+		case "rotate":
+			transform = images.Rotate{Degrees: t.Angle}
+		case "pad":
+			transform = images.Pad{Top: t.Top, Right: t.Right, Bottom: t.Bottom, Left: t.Left}
+		case "opacity":
+			transform = images.Opacity{Amount: amount}
+		case "brightness":
+			transform = images.Brightness{Amount: amount}
+		case "contrast":
+			transform = images.Contrast{Amount: amount}
+		case "saturation":
+			transform = images.Saturation{Amount: amount}
+		case "hue-rotate":
+			transform = images.HueRotate{Degrees: t.Angle}
+		case "grayscale":
+			transform = images.Grayscale{Amount: amount}
+		case "invert":
+			transform = images.Invert{Amount: amount}
+		case "tint":
+			c, err := images.ParseColor(t.Color)
+			if err != nil {
+				return nil, nil, fmt.Errorf("transform %d: %w", index+1, err)
+			}
+			transform = images.Tint{Color: c, Amount: amount}
+		case "color-key":
+			if t.Color == "" || t.Color == "auto" {
+				transform = images.ColorKey{Auto: true, Tolerance: t.Tolerance, Feather: t.Feather}
+				break
+			}
+			c, err := images.ParseColor(t.Color)
+			if err != nil {
+				return nil, nil, fmt.Errorf("transform %d: %w", index+1, err)
+			}
+			transform = images.ColorKey{Color: c, Tolerance: t.Tolerance, Feather: t.Feather}
+		case "outline", "drop-shadow", "glow":
+			c, err := parseEffectColor(t.Color)
+			if err != nil {
+				return nil, nil, fmt.Errorf("transform %d: %w", index+1, err)
+			}
+			switch t.Type {
+			case "outline":
+				transform = images.Outline{Width: t.Width, Color: c}
+			case "drop-shadow":
+				transform = images.DropShadow{OffsetX: t.OffsetX, OffsetY: t.OffsetY, Blur: t.Blur, Color: c}
+			case "glow":
+				transform = images.Glow{Radius: t.Radius, Strength: amount, Color: c}
+			}
+		default:
+			return nil, nil, fmt.Errorf("transform %d: unknown type %q", index+1, t.Type)
+		}
+
+		if amount < 0 {
+			return nil, nil, fmt.Errorf("transform %d: %s amount must not be negative", index+1, t.Type)
+		}
+
+		*pipeline = append(*pipeline, transform)
+	}
+
+	if !resized {
+		return after, nil, nil
+	}
+
+	return before, after, nil
+}
+
+func isEffect(transform string) bool {
+	switch transform {
+	case "outline", "drop-shadow", "glow":
+		return true
+	}
+	return false
+}
+
+func parseEffectColor(c string) (color.NRGBA, error) {
+	if c == "" {
+		return color.NRGBA{A: 255}, nil
 	}
+	return images.ParseColor(c)
 }
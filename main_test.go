@@ -0,0 +1,72 @@
+package main
+
+import (
+	"image"
+	"image/color"
+	"testing"
+
+	"github.com/fluffy-melli/visualio/config"
+	"github.com/fluffy-melli/visualio/graphics"
+)
+
+func TestEffectsKeepSpriteOrigin(t *testing.T) {
+	sprite := image.NewRGBA(image.Rect(0, 0, 10, 10))
+	for i := range sprite.Pix {
+		sprite.Pix[i] = 255
+	}
+
+	tests := []struct {
+		name       string
+		transforms []config.Transform
+		want       image.Rectangle
+		opaque     image.Point
+	}{
+		{
+			name:       "no effects",
+			transforms: nil,
+			want:       image.Rect(0, 0, 20, 20),
+			opaque:     image.Pt(10, 10),
+		},
+		{
+			name:       "outline runs after resize",
+			transforms: []config.Transform{{Type: "outline", Width: 3}},
+			want:       image.Rect(-4, -4, 24, 24),
+			opaque:     image.Pt(10, 10),
+		},
+		{
+			name:       "outline before the resize marker keeps the scaled origin",
+			transforms: []config.Transform{{Type: "outline", Width: 3}, {Type: "resize"}},
+			want:       image.Rect(-8, -8, 28, 28),
+			opaque:     image.Pt(10, 10),
+		},
+		{
+			name:       "filters before the first effect still run before resize",
+			transforms: []config.Transform{{Type: "pad", Left: 10}, {Type: "glow", Radius: 2}},
+			want:       image.Rect(-3, -3, 43, 23),
+			opaque:     image.Pt(30, 10),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			overlay := config.Overlay{
+				ImageResize: config.ImageResize{Width: "200%", Height: "200%"},
+				Transforms:  tt.transforms,
+			}
+
+			screen, err := newOverlay(&config.Config{}, overlay)
+			if err != nil {
+				t.Fatal(err)
+			}
+			screen.SetBackend(graphics.NewMemoryBackend(1920, 1080))
+
+			got := screen.OnImage(screen, sprite)
+			if got.Bounds() != tt.want {
+				t.Fatalf("bounds = %v, want %v", got.Bounds(), tt.want)
+			}
+			if c := color.RGBAModel.Convert(got.At(tt.opaque.X, tt.opaque.Y)).(color.RGBA); c != (color.RGBA{R: 255, G: 255, B: 255, A: 255}) {
+				t.Fatalf("sprite moved: pixel %v = %v", tt.opaque, c)
+			}
+		})
+	}
+}
@@ -0,0 +1,57 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/fluffy-melli/visualio/config"
+	"github.com/fluffy-melli/visualio/graphics"
+)
+
+func applyConfig(screens []*graphics.Render, previous, current *config.Config) error {
+	before, after := previous.AllOverlays(), current.AllOverlays()
+
+	for index := range min(len(before), len(after), len(screens)) {
+		if err := applyOverlay(screens[index], current, before[index], after[index]); err != nil {
+			return fmt.Errorf("%s: %w", overlayLabel(index, after[index]), err)
+		}
+	}
+
+	if len(before) != len(after) {
+		return fmt.Errorf("overlay count changed from %d to %d, restart visualio to apply", len(before), len(after))
+	}
+	return nil
+}
+
+func applyOverlay(screen *graphics.Render, configs *config.Config, before, after config.Overlay) error {
+	prepared, err := newOverlay(configs, after)
+	if err != nil {
+		return err
+	}
+
+	screen.SetOptions(prepared)
+
+	if prepared.CacheBudget != screen.CacheBudget {
+		screen.SetCacheBudget(prepared.CacheBudget)
+	}
+
+	if prepared.ProcessorKey != screen.ProcessorKey {
+		screen.SetProcessor(prepared.OnImage, prepared.ProcessorKey)
+	}
+
+	if prepared.Playback != screen.Playback {
+		screen.SetPlayback(prepared.Playback)
+	}
+
+	if after.Image.Source != before.Image.Source {
+		if err := screen.Load(after.Image.Source); err != nil {
+			return err
+		}
+	}
+
+	if after.ImagePosition != before.ImagePosition {
+		screen.SetPosition(after.ImagePosition.X, after.ImagePosition.Y)
+	}
+
+	screen.Invalidate()
+	return nil
+}
@@ -0,0 +1,189 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"image"
+	"image/draw"
+	"image/png"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/fluffy-melli/visualio/config"
+	"github.com/fluffy-melli/visualio/graphics"
+	"github.com/fluffy-melli/visualio/images"
+)
+
+func renderCommand(args []string) error {
+	flags := flag.NewFlagSet("render", flag.ContinueOnError)
+
+	configPath := flags.String("config", ConfigFile, "config file to render")
+	overlayName := flags.String("overlay", "", "overlay to render, by name or 1-based number (default: the first overlay)")
+	output := flags.String("o", "", "output file (required)")
+	format := flags.String("format", "", "output format: png, gif or apng (default: from the output extension)")
+	frame := flags.Int("frame", -1, "frame to write for png output; -1 uses the first frame of the playback sequence")
+	width := flags.Int("width", 1920, "viewport width used to resolve vw/% sizes")
+	height := flags.Int("height", 1080, "viewport height used to resolve vh/% sizes")
+	dpi := flags.Int("dpi", graphics.DefaultDPI, "viewport DPI used to resolve dp/pt sizes")
+
+	if err := flags.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return nil
+		}
+		return err
+	}
+
+	if *output == "" {
+		return errors.New("render: -o is required")
+	}
+
+	if *format == "" {
+		*format = strings.TrimPrefix(strings.ToLower(filepath.Ext(*output)), ".")
+	}
+
+	switch *format {
+	case "png", "gif", "apng":
+	default:
+		return fmt.Errorf("render: unknown format %q", *format)
+	}
+
+	if *frame < -1 {
+		return fmt.Errorf("render: -frame must be -1 or a frame index, got %d", *frame)
+	}
+
+	if *format != "png" && *frame >= 0 {
+		return fmt.Errorf("render: -frame only applies to png output")
+	}
+
+	configs, err := config.Read(*configPath)
+	if err != nil {
+		return err
+	}
+
+	index, overlay, err := selectOverlay(configs.AllOverlays(), *overlayName)
+	if err != nil {
+		return err
+	}
+
+	screen, err := newOverlay(configs, overlay)
+	if err != nil {
+		return fmt.Errorf("%s: %w", overlayLabel(index, overlay), err)
+	}
+
+	backend := graphics.NewMemoryBackend(*width, *height)
+	backend.SetDPI(*dpi)
+	screen.SetBackend(backend)
+
+	if err := screen.Load(overlay.Image.Source); err != nil {
+		return fmt.Errorf("%s: %w", overlayLabel(index, overlay), err)
+	}
+
+	animator := screen.Animator()
+	defer animator.Cleanup()
+
+	animation, err := renderAnimation(animator, screen, *frame, *format == "png")
+	if err != nil {
+		return err
+	}
+
+	file, err := os.Create(*output)
+	if err != nil {
+		return err
+	}
+
+	err = encodeAnimation(file, animation, *format)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
+
+func selectOverlay(overlays []config.Overlay, name string) (int, config.Overlay, error) {
+	if len(overlays) == 0 {
+		return 0, config.Overlay{}, errors.New("render: config has no image source")
+	}
+
+	if name == "" {
+		return 0, overlays[0], nil
+	}
+
+	for index, overlay := range overlays {
+		if overlay.Name == name {
+			return index, overlay, nil
+		}
+	}
+
+	if number, err := strconv.Atoi(name); err == nil && number >= 1 && number <= len(overlays) {
+		return number - 1, overlays[number-1], nil
+	}
+
+	return 0, config.Overlay{}, fmt.Errorf("render: unknown overlay %q", name)
+}
+
+func renderAnimation(animator *graphics.Animator, screen *graphics.Render, frame int, still bool) (*images.Animation, error) {
+	sequence := animator.Sequence()
+	delays := animator.FrameDelays()
+
+	if still {
+		if frame < 0 {
+			frame = sequence[0]
+		} else if !slices.Contains(sequence, frame) {
+			return nil, fmt.Errorf("render: -frame %d is outside the playback sequence (frames %d-%d)", frame, slices.Min(sequence), slices.Max(sequence))
+		}
+		sequence = []int{frame}
+		delays = delays[:1]
+	}
+
+	processed := make(map[int]image.Image)
+	var bounds image.Rectangle
+
+	for _, index := range sequence {
+		if _, ok := processed[index]; ok {
+			continue
+		}
+
+		animator.Seek(index)
+		img := animator.GetCurrentImage(screen)
+		if img == nil {
+			return nil, fmt.Errorf("render: frame %d could not be processed", index)
+		}
+
+		processed[index] = img
+		bounds = bounds.Union(img.Bounds())
+	}
+
+	if bounds.Empty() {
+		return nil, errors.New("render: processed image is empty")
+	}
+
+	animation := &images.Animation{
+		Plays: animator.PlayCount(),
+	}
+
+	for i, index := range sequence {
+		canvas := image.NewRGBA(image.Rect(0, 0, bounds.Dx(), bounds.Dy()))
+		img := processed[index]
+		draw.Draw(canvas, img.Bounds().Sub(bounds.Min), img, img.Bounds().Min, draw.Src)
+
+		animation.Frames = append(animation.Frames, canvas)
+		animation.Delays = append(animation.Delays, int(delays[i]/time.Millisecond))
+	}
+
+	return animation, nil
+}
+
+func encodeAnimation(w io.Writer, animation *images.Animation, format string) error {
+	switch format {
+	case "gif":
+		return images.EncodeGIF(w, animation)
+	case "apng":
+		return images.EncodeAPNG(w, animation)
+	}
+	return png.Encode(w, animation.Frames[0])
+}
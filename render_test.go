@@ -0,0 +1,105 @@
+package main
+
+import (
+	"image"
+	"image/color"
+	"image/gif"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/fluffy-melli/visualio/graphics"
+)
+
+func writeTestGIF(t *testing.T, path string, frames int) {
+	t.Helper()
+
+	animation := &gif.GIF{}
+	for i := range frames {
+		frame := image.NewPaletted(image.Rect(0, 0, 2, 2), color.Palette{color.Black, color.RGBA{R: 255, A: 255}})
+		frame.SetColorIndex(0, 0, uint8(i%2))
+		animation.Image = append(animation.Image, frame)
+		animation.Delay = append(animation.Delay, 10)
+	}
+
+	file, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+
+	if err := gif.EncodeAll(file, animation); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func testGIFAnimator(t *testing.T, frames int) *graphics.Animator {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "test.gif")
+	writeTestGIF(t, path, frames)
+
+	animator, err := graphics.NewAnimator(path, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(animator.Cleanup)
+	return animator
+}
+
+func TestRenderAnimationFrame(t *testing.T) {
+	screen := graphics.NewScreen()
+
+	for frame, red := range map[int]uint8{-1: 0, 0: 0, 1: 255, 2: 0} {
+		animation, err := renderAnimation(testGIFAnimator(t, 3), screen, frame, true)
+		if err != nil {
+			t.Fatalf("-frame %d: %v", frame, err)
+		}
+		if len(animation.Frames) != 1 {
+			t.Fatalf("-frame %d rendered %d frames", frame, len(animation.Frames))
+		}
+		if got := animation.Frames[0].RGBAAt(0, 0).R; got != red {
+			t.Fatalf("-frame %d rendered red %d, want %d", frame, got, red)
+		}
+	}
+
+	for _, frame := range []int{3, 100} {
+		_, err := renderAnimation(testGIFAnimator(t, 3), screen, frame, true)
+		if err == nil || !strings.Contains(err.Error(), "outside the playback sequence") {
+			t.Fatalf("-frame %d: err = %v, want an out of range error", frame, err)
+		}
+	}
+}
+
+func TestRenderCommandRejectsNegativeFrame(t *testing.T) {
+	err := renderCommand([]string{"-o", filepath.Join(t.TempDir(), "out.png"), "-frame", "-2"})
+	if err == nil || !strings.Contains(err.Error(), "-frame must be -1") {
+		t.Fatalf("err = %v, want a -frame error", err)
+	}
+}
+
+func TestRenderCommandLeavesLegacyConfig(t *testing.T) {
+	dir := t.TempDir()
+	writeTestGIF(t, filepath.Join(dir, "mascot.gif"), 2)
+
+	configPath := filepath.Join(dir, "config.toml")
+	legacy := []byte("# mine\nimage = \"" + filepath.ToSlash(filepath.Join(dir, "mascot.gif")) + "\"\n")
+	if err := os.WriteFile(configPath, legacy, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := renderCommand([]string{"-config", configPath, "-o", filepath.Join(dir, "out.gif")}); err != nil {
+		t.Fatal(err)
+	}
+
+	if data, _ := os.ReadFile(configPath); string(data) != string(legacy) {
+		t.Fatalf("render rewrote the config:\n%s", data)
+	}
+	if backups, _ := filepath.Glob(configPath + ".*.bak"); len(backups) > 0 {
+		t.Fatalf("render wrote backups %v", backups)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "out.gif")); err != nil {
+		t.Fatal(err)
+	}
+}
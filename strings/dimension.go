@@ -0,0 +1,392 @@
+package strings
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"unicode"
+)
+
+const DefaultDPI = 96
+
+var units = map[string]bool{
+	"%":    true,
+	"px":   true,
+	"vw":   true,
+	"vh":   true,
+	"vmin": true,
+	"vmax": true,
+	"dp":   true,
+	"pt":   true,
+}
+
+type NumberUnit struct {
+	Number string
+	Unit   string
+	Value  float64
+}
+
+type Dimension struct {
+	Terms []NumberUnit
+}
+
+type Viewport struct {
+	Reference float64
+	Width     float64
+	Height    float64
+	DPI       float64
+}
+
+type DimensionError struct {
+	Input  string
+	Column int
+	Reason string
+}
+
+func (e *DimensionError) Error() string {
+	if e.Column > 0 {
+		return fmt.Sprintf("invalid dimension %q at column %d: %s", e.Input, e.Column, e.Reason)
+	}
+	return fmt.Sprintf("invalid dimension %q: %s", e.Input, e.Reason)
+}
+
+func ParseDimension(input string) (Dimension, error) {
+	p := &dimensionParser{input: []rune(input), source: input}
+
+	p.skipSpace()
+	if p.done() {
+		return Dimension{}, p.invalid("empty value")
+	}
+
+	var value linear
+	var err error
+
+	if ratio, ok := p.ratio(); ok {
+		value = ratio
+	} else if value, err = p.expression(); err != nil {
+		return Dimension{}, err
+	}
+
+	p.skipSpace()
+	if !p.done() {
+		return Dimension{}, p.fail(p.pos, fmt.Sprintf("unexpected %q", string(p.input[p.pos])))
+	}
+
+	if scalar, ok := value[""]; ok {
+		if len(value) > 1 || p.calc {
+			return Dimension{}, p.invalid("calc result has no unit")
+		}
+		delete(value, "")
+		value["px"] = scalar
+	}
+
+	return value.dimension(), nil
+}
+
+func (d Dimension) Resolve(v Viewport) float64 {
+	dpi := v.DPI
+	if dpi <= 0 {
+		dpi = DefaultDPI
+	}
+
+	var total float64
+	for _, term := range d.Terms {
+		switch term.Unit {
+		case "%":
+			total += term.Value * v.Reference / 100
+		case "px":
+			total += term.Value
+		case "vw":
+			total += term.Value * v.Width / 100
+		case "vh":
+			total += term.Value * v.Height / 100
+		case "vmin":
+			total += term.Value * min(v.Width, v.Height) / 100
+		case "vmax":
+			total += term.Value * max(v.Width, v.Height) / 100
+		case "dp":
+			total += term.Value * dpi / DefaultDPI
+		case "pt":
+			total += term.Value * dpi / 72
+		}
+	}
+
+	return total
+}
+
+func (d Dimension) String() string {
+	if len(d.Terms) == 1 {
+		return d.Terms[0].Number + d.Terms[0].Unit
+	}
+
+	s := "calc("
+	for i, term := range d.Terms {
+		number := term.Number
+		if i > 0 {
+			if term.Value < 0 {
+				s += " - "
+				number = strconv.FormatFloat(-term.Value, 'g', -1, 64)
+			} else {
+				s += " + "
+			}
+		}
+		s += number + term.Unit
+	}
+	return s + ")"
+}
+
+type linear map[string]float64
+
+func (l linear) dimension() Dimension {
+	keys := make([]string, 0, len(l))
+	for unit := range l {
+		keys = append(keys, unit)
+	}
+	sort.Strings(keys)
+
+	var d Dimension
+	for _, unit := range keys {
+		d.Terms = append(d.Terms, NumberUnit{
+			Number: strconv.FormatFloat(l[unit], 'g', -1, 64),
+			Unit:   unit,
+			Value:  l[unit],
+		})
+	}
+	return d
+}
+
+func (l linear) scalar() (float64, bool) {
+	value, ok := l[""]
+	return value, len(l) == 0 || (ok && len(l) == 1)
+}
+
+func (l linear) scale(factor float64) linear {
+	out := make(linear, len(l))
+	for unit, value := range l {
+		out[unit] = value * factor
+	}
+	return out
+}
+
+func (l linear) add(other linear, sign float64) linear {
+	out := make(linear, len(l)+len(other))
+	for unit, value := range l {
+		out[unit] = value
+	}
+	for unit, value := range other {
+		out[unit] += sign * value
+	}
+	return out
+}
+
+type dimensionParser struct {
+	input  []rune
+	source string
+	pos    int
+	calc   bool
+}
+
+func (p *dimensionParser) fail(pos int, reason string) error {
+	return &DimensionError{Input: p.source, Column: pos + 1, Reason: reason}
+}
+
+func (p *dimensionParser) invalid(reason string) error {
+	return &DimensionError{Input: p.source, Reason: reason}
+}
+
+func (p *dimensionParser) done() bool {
+	return p.pos >= len(p.input)
+}
+
+func (p *dimensionParser) peek() rune {
+	if p.done() {
+		return 0
+	}
+	return p.input[p.pos]
+}
+
+func (p *dimensionParser) skipSpace() {
+	for !p.done() && unicode.IsSpace(p.input[p.pos]) {
+		p.pos++
+	}
+}
+
+func (p *dimensionParser) ratio() (linear, bool) {
+	start := p.pos
+
+	numerator, ok := p.number()
+	if ok && p.peek() == '/' {
+		p.pos++
+		denominator, ok := p.number()
+		p.skipSpace()
+		if ok && denominator != 0 && p.done() {
+			return linear{"%": numerator / denominator * 100}, true
+		}
+	}
+
+	p.pos = start
+	return nil, false
+}
+
+func (p *dimensionParser) expression() (linear, error) {
+	left, err := p.term()
+	if err != nil {
+		return nil, err
+	}
+
+	for {
+		p.skipSpace()
+		op := p.peek()
+		if op != '+' && op != '-' {
+			return left, nil
+		}
+		p.pos++
+
+		right, err := p.term()
+		if err != nil {
+			return nil, err
+		}
+
+		if op == '+' {
+			left = left.add(right, 1)
+		} else {
+			left = left.add(right, -1)
+		}
+	}
+}
+
+func (p *dimensionParser) term() (linear, error) {
+	left, err := p.factor()
+	if err != nil {
+		return nil, err
+	}
+
+	for {
+		p.skipSpace()
+		op := p.peek()
+		if op != '*' && op != '/' {
+			return left, nil
+		}
+		at := p.pos
+		p.pos++
+
+		right, err := p.factor()
+		if err != nil {
+			return nil, err
+		}
+
+		factor, rightScalar := right.scalar()
+		if op == '*' {
+			if !rightScalar {
+				scalar, leftScalar := left.scalar()
+				if !leftScalar {
+					return nil, p.fail(at, "cannot multiply two values with units")
+				}
+				left, factor = right, scalar
+			}
+			left = left.scale(factor)
+			continue
+		}
+
+		if !rightScalar {
+			return nil, p.fail(at, "cannot divide by a value with a unit")
+		}
+		if factor == 0 {
+			return nil, p.fail(at, "division by zero")
+		}
+		left = left.scale(1 / factor)
+	}
+}
+
+func (p *dimensionParser) factor() (linear, error) {
+	p.skipSpace()
+	if p.done() {
+		return nil, p.fail(p.pos, "unexpected end of value")
+	}
+
+	switch r := p.peek(); {
+	case r == '-' || r == '+':
+		p.pos++
+		value, err := p.factor()
+		if err != nil {
+			return nil, err
+		}
+		if r == '-' {
+			value = value.scale(-1)
+		}
+		return value, nil
+
+	case r == '(':
+		return p.group()
+
+	case unicode.IsLetter(r):
+		start := p.pos
+		name := p.word()
+		if name != "calc" || p.peek() != '(' {
+			return nil, p.fail(start, fmt.Sprintf("unknown function %q", name))
+		}
+		p.calc = true
+		return p.group()
+	}
+
+	start := p.pos
+	value, ok := p.number()
+	if !ok {
+		return nil, p.fail(start, fmt.Sprintf("unexpected %q", string(p.input[start])))
+	}
+
+	unitStart := p.pos
+	unit := p.word()
+	if p.peek() == '%' && unit == "" {
+		p.pos++
+		unit = "%"
+	}
+	if unit != "" && !units[unit] {
+		return nil, p.fail(unitStart, fmt.Sprintf("unknown unit %q", unit))
+	}
+
+	return linear{unit: value}, nil
+}
+
+func (p *dimensionParser) group() (linear, error) {
+	open := p.pos
+	p.pos++
+
+	value, err := p.expression()
+	if err != nil {
+		return nil, err
+	}
+
+	p.skipSpace()
+	if p.peek() != ')' {
+		return nil, p.fail(open, "unclosed parenthesis")
+	}
+	p.pos++
+
+	return value, nil
+}
+
+func (p *dimensionParser) number() (float64, bool) {
+	start := p.pos
+	for !p.done() && (unicode.IsDigit(p.input[p.pos]) || p.input[p.pos] == '.') {
+		p.pos++
+	}
+	if start == p.pos {
+		return 0, false
+	}
+
+	value, err := strconv.ParseFloat(string(p.input[start:p.pos]), 64)
+	if err != nil {
+		p.pos = start
+		return 0, false
+	}
+	return value, true
+}
+
+func (p *dimensionParser) word() string {
+	start := p.pos
+	for !p.done() && unicode.IsLetter(p.input[p.pos]) {
+		p.pos++
+	}
+	return string(p.input[start:p.pos])
+}
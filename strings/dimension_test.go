@@ -0,0 +1,98 @@
+package strings
+
+import (
+	"errors"
+	"math"
+	"testing"
+)
+
+func TestParseDimension(t *testing.T) {
+	viewport := Viewport{Reference: 200, Width: 1000, Height: 500, DPI: 192}
+
+	tests := []struct {
+		input  string
+		want   string
+		pixels float64
+	}{
+		{input: "50%", want: "50%", pixels: 100},
+		{input: "120px", want: "120px", pixels: 120},
+		{input: "12", want: "12px", pixels: 12},
+		{input: "-20px", want: "-20px", pixels: -20},
+		{input: "10vw", want: "10vw", pixels: 100},
+		{input: "10vh", want: "10vh", pixels: 50},
+		{input: "10vmin", want: "10vmin", pixels: 50},
+		{input: "10vmax", want: "10vmax", pixels: 100},
+		{input: "10dp", want: "10dp", pixels: 20},
+		{input: "9pt", want: "9pt", pixels: 24},
+		{input: "1/3", want: "33.33333333333333%", pixels: 200.0 / 3},
+		{input: "2/4", want: "50%", pixels: 100},
+		{input: "calc(50%)", want: "50%", pixels: 100},
+		{input: "calc(50% - 20px)", want: "calc(50% - 20px)", pixels: 80},
+		{input: "calc(-10% + 3vw)", want: "calc(-10% + 3vw)", pixels: 10},
+		{input: "calc(10px + 2 * 5px)", want: "20px", pixels: 20},
+		{input: "calc(100% - (10px + 5px) * 2)", want: "calc(100% - 30px)", pixels: 170},
+		{input: "calc(calc(10vw) / 2 + 3pt)", want: "calc(3pt + 5vw)", pixels: 58},
+		{input: " calc( 50% / 2 ) ", want: "25%", pixels: 50},
+	}
+
+	for _, tt := range tests {
+		got, err := ParseDimension(tt.input)
+		if err != nil {
+			t.Errorf("ParseDimension(%q): %v", tt.input, err)
+			continue
+		}
+		if got.String() != tt.want {
+			t.Errorf("ParseDimension(%q) = %s, want %s", tt.input, got, tt.want)
+		}
+		if pixels := got.Resolve(viewport); math.Abs(pixels-tt.pixels) > 1e-9 {
+			t.Errorf("ParseDimension(%q) resolved to %v, want %v", tt.input, pixels, tt.pixels)
+		}
+	}
+}
+
+func TestParseDimensionErrors(t *testing.T) {
+	tests := []struct {
+		input  string
+		column int
+		want   string
+	}{
+		{input: "", want: `invalid dimension "": empty value`},
+		{input: "  ", want: `invalid dimension "  ": empty value`},
+		{input: "1/0", column: 2, want: `invalid dimension "1/0" at column 2: division by zero`},
+		{input: "calc(1px / 0)", column: 10, want: `invalid dimension "calc(1px / 0)" at column 10: division by zero`},
+		{input: "10em", column: 3, want: `invalid dimension "10em" at column 3: unknown unit "em"`},
+		{input: "50%%", column: 4, want: `invalid dimension "50%%" at column 4: unexpected "%"`},
+		{input: "10px 5px", column: 6, want: `invalid dimension "10px 5px" at column 6: unexpected "5"`},
+		{input: "1.2.3px", column: 1, want: `invalid dimension "1.2.3px" at column 1: unexpected "1"`},
+		{input: "width", column: 1, want: `invalid dimension "width" at column 1: unknown function "width"`},
+		{input: "calc(50% - 20px", column: 5, want: `invalid dimension "calc(50% - 20px" at column 5: unclosed parenthesis`},
+		{input: "calc(1px +)", column: 11, want: `invalid dimension "calc(1px +)" at column 11: unexpected ")"`},
+		{input: "calc(1px * 2px)", column: 10, want: `invalid dimension "calc(1px * 2px)" at column 10: cannot multiply two values with units`},
+		{input: "calc(1px / 2px)", column: 10, want: `invalid dimension "calc(1px / 2px)" at column 10: cannot divide by a value with a unit`},
+		{input: "calc(2 * 3)", want: `invalid dimension "calc(2 * 3)": calc result has no unit`},
+	}
+
+	for _, tt := range tests {
+		_, err := ParseDimension(tt.input)
+		var dimensionErr *DimensionError
+		if !errors.As(err, &dimensionErr) {
+			t.Errorf("ParseDimension(%q) error = %v, want a DimensionError", tt.input, err)
+			continue
+		}
+		if dimensionErr.Column != tt.column || err.Error() != tt.want {
+			t.Errorf("ParseDimension(%q) error = %q at column %d, want %q at column %d", tt.input, err, dimensionErr.Column, tt.want, tt.column)
+		}
+	}
+}
+
+func TestResolveDefaultDPI(t *testing.T) {
+	for input, want := range map[string]float64{"10dp": 10, "9pt": 12} {
+		d, err := ParseDimension(input)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := d.Resolve(Viewport{}); got != want {
+			t.Errorf("%s at the default DPI = %v, want %v", input, got, want)
+		}
+	}
+}
@@ -0,0 +1,54 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+
+	"github.com/fluffy-melli/visualio/config"
+)
+
+func configCommand(args []string) error {
+	if len(args) == 0 || (args[0] != "validate" && args[0] != "migrate") {
+		return errors.New("usage: visualio config validate|migrate [-config path]")
+	}
+
+	flags := flag.NewFlagSet("config "+args[0], flag.ContinueOnError)
+	configPath := flags.String("config", ConfigFile, "config file to "+args[0])
+
+	if err := flags.Parse(args[1:]); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return nil
+		}
+		return err
+	}
+
+	if args[0] == "migrate" {
+		return migrateConfig(*configPath)
+	}
+
+	if err := config.Validate(*configPath); err != nil {
+		return err
+	}
+
+	fmt.Printf("%s: ok\n", *configPath)
+	return nil
+}
+
+func migrateConfig(configPath string) error {
+	backup, steps, err := config.Migrate(configPath)
+	if err != nil {
+		return err
+	}
+
+	if len(steps) == 0 {
+		fmt.Printf("%s: already at schema %d\n", configPath, config.CurrentSchema)
+		return nil
+	}
+
+	for _, step := range steps {
+		fmt.Printf("%s: %s\n", configPath, step)
+	}
+	fmt.Printf("%s: original saved to %s\n", configPath, backup)
+	return nil
+}